@@ -19,11 +19,23 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	ProjectService_CreateProject_FullMethodName  = "/ProjectService/CreateProject"
-	ProjectService_GetProject_FullMethodName     = "/ProjectService/GetProject"
-	ProjectService_GetAllProjects_FullMethodName = "/ProjectService/GetAllProjects"
-	ProjectService_UpdateProject_FullMethodName  = "/ProjectService/UpdateProject"
-	ProjectService_DeleteProject_FullMethodName  = "/ProjectService/DeleteProject"
+	ProjectService_CreateProject_FullMethodName       = "/ProjectService/CreateProject"
+	ProjectService_GetProject_FullMethodName          = "/ProjectService/GetProject"
+	ProjectService_GetAllProjects_FullMethodName      = "/ProjectService/GetAllProjects"
+	ProjectService_SearchProjects_FullMethodName      = "/ProjectService/SearchProjects"
+	ProjectService_UpdateProject_FullMethodName       = "/ProjectService/UpdateProject"
+	ProjectService_DeleteProject_FullMethodName       = "/ProjectService/DeleteProject"
+	ProjectService_RestoreProject_FullMethodName      = "/ProjectService/RestoreProject"
+	ProjectService_ArchiveProject_FullMethodName      = "/ProjectService/ArchiveProject"
+	ProjectService_UnarchiveProject_FullMethodName    = "/ProjectService/UnarchiveProject"
+	ProjectService_TransitionProject_FullMethodName   = "/ProjectService/TransitionProject"
+	ProjectService_AddProjectMember_FullMethodName    = "/ProjectService/AddProjectMember"
+	ProjectService_ListProjectMembers_FullMethodName  = "/ProjectService/ListProjectMembers"
+	ProjectService_UpdateProjectMember_FullMethodName = "/ProjectService/UpdateProjectMember"
+	ProjectService_RemoveProjectMember_FullMethodName = "/ProjectService/RemoveProjectMember"
+	ProjectService_ListProjectHistory_FullMethodName  = "/ProjectService/ListProjectHistory"
+	ProjectService_GetProjectAtVersion_FullMethodName = "/ProjectService/GetProjectAtVersion"
+	ProjectService_RevertProject_FullMethodName       = "/ProjectService/RevertProject"
 )
 
 // ProjectServiceClient is the client API for ProjectService service.
@@ -33,8 +45,20 @@ type ProjectServiceClient interface {
 	CreateProject(ctx context.Context, in *CreateProjectRequest, opts ...grpc.CallOption) (*CreateProjectResponse, error)
 	GetProject(ctx context.Context, in *GetProjectRequest, opts ...grpc.CallOption) (*GetProjectResponse, error)
 	GetAllProjects(ctx context.Context, in *GetAllProjectsRequest, opts ...grpc.CallOption) (*GetAllProjectsResponse, error)
+	SearchProjects(ctx context.Context, in *SearchProjectsRequest, opts ...grpc.CallOption) (*SearchProjectsResponse, error)
 	UpdateProject(ctx context.Context, in *UpdateProjectRequest, opts ...grpc.CallOption) (*UpdateProjectResponse, error)
 	DeleteProject(ctx context.Context, in *DeleteProjectRequest, opts ...grpc.CallOption) (*DeleteProjectResponse, error)
+	RestoreProject(ctx context.Context, in *RestoreProjectRequest, opts ...grpc.CallOption) (*RestoreProjectResponse, error)
+	ArchiveProject(ctx context.Context, in *ArchiveProjectRequest, opts ...grpc.CallOption) (*ArchiveProjectResponse, error)
+	UnarchiveProject(ctx context.Context, in *UnarchiveProjectRequest, opts ...grpc.CallOption) (*UnarchiveProjectResponse, error)
+	TransitionProject(ctx context.Context, in *TransitionProjectRequest, opts ...grpc.CallOption) (*TransitionProjectResponse, error)
+	AddProjectMember(ctx context.Context, in *AddProjectMemberRequest, opts ...grpc.CallOption) (*AddProjectMemberResponse, error)
+	ListProjectMembers(ctx context.Context, in *ListProjectMembersRequest, opts ...grpc.CallOption) (*ListProjectMembersResponse, error)
+	UpdateProjectMember(ctx context.Context, in *UpdateProjectMemberRequest, opts ...grpc.CallOption) (*UpdateProjectMemberResponse, error)
+	RemoveProjectMember(ctx context.Context, in *RemoveProjectMemberRequest, opts ...grpc.CallOption) (*RemoveProjectMemberResponse, error)
+	ListProjectHistory(ctx context.Context, in *ListProjectHistoryRequest, opts ...grpc.CallOption) (*ListProjectHistoryResponse, error)
+	GetProjectAtVersion(ctx context.Context, in *GetProjectAtVersionRequest, opts ...grpc.CallOption) (*GetProjectAtVersionResponse, error)
+	RevertProject(ctx context.Context, in *RevertProjectRequest, opts ...grpc.CallOption) (*RevertProjectResponse, error)
 }
 
 type projectServiceClient struct {
@@ -72,6 +96,15 @@ func (c *projectServiceClient) GetAllProjects(ctx context.Context, in *GetAllPro
 	return out, nil
 }
 
+func (c *projectServiceClient) SearchProjects(ctx context.Context, in *SearchProjectsRequest, opts ...grpc.CallOption) (*SearchProjectsResponse, error) {
+	out := new(SearchProjectsResponse)
+	err := c.cc.Invoke(ctx, ProjectService_SearchProjects_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *projectServiceClient) UpdateProject(ctx context.Context, in *UpdateProjectRequest, opts ...grpc.CallOption) (*UpdateProjectResponse, error) {
 	out := new(UpdateProjectResponse)
 	err := c.cc.Invoke(ctx, ProjectService_UpdateProject_FullMethodName, in, out, opts...)
@@ -90,6 +123,105 @@ func (c *projectServiceClient) DeleteProject(ctx context.Context, in *DeleteProj
 	return out, nil
 }
 
+func (c *projectServiceClient) RestoreProject(ctx context.Context, in *RestoreProjectRequest, opts ...grpc.CallOption) (*RestoreProjectResponse, error) {
+	out := new(RestoreProjectResponse)
+	err := c.cc.Invoke(ctx, ProjectService_RestoreProject_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *projectServiceClient) ArchiveProject(ctx context.Context, in *ArchiveProjectRequest, opts ...grpc.CallOption) (*ArchiveProjectResponse, error) {
+	out := new(ArchiveProjectResponse)
+	err := c.cc.Invoke(ctx, ProjectService_ArchiveProject_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *projectServiceClient) UnarchiveProject(ctx context.Context, in *UnarchiveProjectRequest, opts ...grpc.CallOption) (*UnarchiveProjectResponse, error) {
+	out := new(UnarchiveProjectResponse)
+	err := c.cc.Invoke(ctx, ProjectService_UnarchiveProject_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *projectServiceClient) TransitionProject(ctx context.Context, in *TransitionProjectRequest, opts ...grpc.CallOption) (*TransitionProjectResponse, error) {
+	out := new(TransitionProjectResponse)
+	err := c.cc.Invoke(ctx, ProjectService_TransitionProject_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *projectServiceClient) AddProjectMember(ctx context.Context, in *AddProjectMemberRequest, opts ...grpc.CallOption) (*AddProjectMemberResponse, error) {
+	out := new(AddProjectMemberResponse)
+	err := c.cc.Invoke(ctx, ProjectService_AddProjectMember_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *projectServiceClient) ListProjectMembers(ctx context.Context, in *ListProjectMembersRequest, opts ...grpc.CallOption) (*ListProjectMembersResponse, error) {
+	out := new(ListProjectMembersResponse)
+	err := c.cc.Invoke(ctx, ProjectService_ListProjectMembers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *projectServiceClient) UpdateProjectMember(ctx context.Context, in *UpdateProjectMemberRequest, opts ...grpc.CallOption) (*UpdateProjectMemberResponse, error) {
+	out := new(UpdateProjectMemberResponse)
+	err := c.cc.Invoke(ctx, ProjectService_UpdateProjectMember_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *projectServiceClient) RemoveProjectMember(ctx context.Context, in *RemoveProjectMemberRequest, opts ...grpc.CallOption) (*RemoveProjectMemberResponse, error) {
+	out := new(RemoveProjectMemberResponse)
+	err := c.cc.Invoke(ctx, ProjectService_RemoveProjectMember_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *projectServiceClient) ListProjectHistory(ctx context.Context, in *ListProjectHistoryRequest, opts ...grpc.CallOption) (*ListProjectHistoryResponse, error) {
+	out := new(ListProjectHistoryResponse)
+	err := c.cc.Invoke(ctx, ProjectService_ListProjectHistory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *projectServiceClient) GetProjectAtVersion(ctx context.Context, in *GetProjectAtVersionRequest, opts ...grpc.CallOption) (*GetProjectAtVersionResponse, error) {
+	out := new(GetProjectAtVersionResponse)
+	err := c.cc.Invoke(ctx, ProjectService_GetProjectAtVersion_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *projectServiceClient) RevertProject(ctx context.Context, in *RevertProjectRequest, opts ...grpc.CallOption) (*RevertProjectResponse, error) {
+	out := new(RevertProjectResponse)
+	err := c.cc.Invoke(ctx, ProjectService_RevertProject_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ProjectServiceServer is the server API for ProjectService service.
 // All implementations must embed UnimplementedProjectServiceServer
 // for forward compatibility
@@ -97,8 +229,20 @@ type ProjectServiceServer interface {
 	CreateProject(context.Context, *CreateProjectRequest) (*CreateProjectResponse, error)
 	GetProject(context.Context, *GetProjectRequest) (*GetProjectResponse, error)
 	GetAllProjects(context.Context, *GetAllProjectsRequest) (*GetAllProjectsResponse, error)
+	SearchProjects(context.Context, *SearchProjectsRequest) (*SearchProjectsResponse, error)
 	UpdateProject(context.Context, *UpdateProjectRequest) (*UpdateProjectResponse, error)
 	DeleteProject(context.Context, *DeleteProjectRequest) (*DeleteProjectResponse, error)
+	RestoreProject(context.Context, *RestoreProjectRequest) (*RestoreProjectResponse, error)
+	ArchiveProject(context.Context, *ArchiveProjectRequest) (*ArchiveProjectResponse, error)
+	UnarchiveProject(context.Context, *UnarchiveProjectRequest) (*UnarchiveProjectResponse, error)
+	TransitionProject(context.Context, *TransitionProjectRequest) (*TransitionProjectResponse, error)
+	AddProjectMember(context.Context, *AddProjectMemberRequest) (*AddProjectMemberResponse, error)
+	ListProjectMembers(context.Context, *ListProjectMembersRequest) (*ListProjectMembersResponse, error)
+	UpdateProjectMember(context.Context, *UpdateProjectMemberRequest) (*UpdateProjectMemberResponse, error)
+	RemoveProjectMember(context.Context, *RemoveProjectMemberRequest) (*RemoveProjectMemberResponse, error)
+	ListProjectHistory(context.Context, *ListProjectHistoryRequest) (*ListProjectHistoryResponse, error)
+	GetProjectAtVersion(context.Context, *GetProjectAtVersionRequest) (*GetProjectAtVersionResponse, error)
+	RevertProject(context.Context, *RevertProjectRequest) (*RevertProjectResponse, error)
 	mustEmbedUnimplementedProjectServiceServer()
 }
 
@@ -115,12 +259,48 @@ func (UnimplementedProjectServiceServer) GetProject(context.Context, *GetProject
 func (UnimplementedProjectServiceServer) GetAllProjects(context.Context, *GetAllProjectsRequest) (*GetAllProjectsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetAllProjects not implemented")
 }
+func (UnimplementedProjectServiceServer) SearchProjects(context.Context, *SearchProjectsRequest) (*SearchProjectsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchProjects not implemented")
+}
 func (UnimplementedProjectServiceServer) UpdateProject(context.Context, *UpdateProjectRequest) (*UpdateProjectResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateProject not implemented")
 }
 func (UnimplementedProjectServiceServer) DeleteProject(context.Context, *DeleteProjectRequest) (*DeleteProjectResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteProject not implemented")
 }
+func (UnimplementedProjectServiceServer) RestoreProject(context.Context, *RestoreProjectRequest) (*RestoreProjectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreProject not implemented")
+}
+func (UnimplementedProjectServiceServer) ArchiveProject(context.Context, *ArchiveProjectRequest) (*ArchiveProjectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ArchiveProject not implemented")
+}
+func (UnimplementedProjectServiceServer) UnarchiveProject(context.Context, *UnarchiveProjectRequest) (*UnarchiveProjectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnarchiveProject not implemented")
+}
+func (UnimplementedProjectServiceServer) TransitionProject(context.Context, *TransitionProjectRequest) (*TransitionProjectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransitionProject not implemented")
+}
+func (UnimplementedProjectServiceServer) AddProjectMember(context.Context, *AddProjectMemberRequest) (*AddProjectMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddProjectMember not implemented")
+}
+func (UnimplementedProjectServiceServer) ListProjectMembers(context.Context, *ListProjectMembersRequest) (*ListProjectMembersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProjectMembers not implemented")
+}
+func (UnimplementedProjectServiceServer) UpdateProjectMember(context.Context, *UpdateProjectMemberRequest) (*UpdateProjectMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateProjectMember not implemented")
+}
+func (UnimplementedProjectServiceServer) RemoveProjectMember(context.Context, *RemoveProjectMemberRequest) (*RemoveProjectMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveProjectMember not implemented")
+}
+func (UnimplementedProjectServiceServer) ListProjectHistory(context.Context, *ListProjectHistoryRequest) (*ListProjectHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProjectHistory not implemented")
+}
+func (UnimplementedProjectServiceServer) GetProjectAtVersion(context.Context, *GetProjectAtVersionRequest) (*GetProjectAtVersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProjectAtVersion not implemented")
+}
+func (UnimplementedProjectServiceServer) RevertProject(context.Context, *RevertProjectRequest) (*RevertProjectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevertProject not implemented")
+}
 func (UnimplementedProjectServiceServer) mustEmbedUnimplementedProjectServiceServer() {}
 
 // UnsafeProjectServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -188,6 +368,24 @@ func _ProjectService_GetAllProjects_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ProjectService_SearchProjects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchProjectsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectServiceServer).SearchProjects(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectService_SearchProjects_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectServiceServer).SearchProjects(ctx, req.(*SearchProjectsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ProjectService_UpdateProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UpdateProjectRequest)
 	if err := dec(in); err != nil {
@@ -224,6 +422,204 @@ func _ProjectService_DeleteProject_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ProjectService_RestoreProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectServiceServer).RestoreProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectService_RestoreProject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectServiceServer).RestoreProject(ctx, req.(*RestoreProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProjectService_ArchiveProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ArchiveProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectServiceServer).ArchiveProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectService_ArchiveProject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectServiceServer).ArchiveProject(ctx, req.(*ArchiveProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProjectService_UnarchiveProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnarchiveProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectServiceServer).UnarchiveProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectService_UnarchiveProject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectServiceServer).UnarchiveProject(ctx, req.(*UnarchiveProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProjectService_TransitionProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransitionProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectServiceServer).TransitionProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectService_TransitionProject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectServiceServer).TransitionProject(ctx, req.(*TransitionProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProjectService_AddProjectMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddProjectMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectServiceServer).AddProjectMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectService_AddProjectMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectServiceServer).AddProjectMember(ctx, req.(*AddProjectMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProjectService_ListProjectMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProjectMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectServiceServer).ListProjectMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectService_ListProjectMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectServiceServer).ListProjectMembers(ctx, req.(*ListProjectMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProjectService_UpdateProjectMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateProjectMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectServiceServer).UpdateProjectMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectService_UpdateProjectMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectServiceServer).UpdateProjectMember(ctx, req.(*UpdateProjectMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProjectService_RemoveProjectMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveProjectMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectServiceServer).RemoveProjectMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectService_RemoveProjectMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectServiceServer).RemoveProjectMember(ctx, req.(*RemoveProjectMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProjectService_ListProjectHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProjectHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectServiceServer).ListProjectHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectService_ListProjectHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectServiceServer).ListProjectHistory(ctx, req.(*ListProjectHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProjectService_GetProjectAtVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProjectAtVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectServiceServer).GetProjectAtVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectService_GetProjectAtVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectServiceServer).GetProjectAtVersion(ctx, req.(*GetProjectAtVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProjectService_RevertProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevertProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectServiceServer).RevertProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectService_RevertProject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectServiceServer).RevertProject(ctx, req.(*RevertProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ProjectService_ServiceDesc is the grpc.ServiceDesc for ProjectService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -243,6 +639,10 @@ var ProjectService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetAllProjects",
 			Handler:    _ProjectService_GetAllProjects_Handler,
 		},
+		{
+			MethodName: "SearchProjects",
+			Handler:    _ProjectService_SearchProjects_Handler,
+		},
 		{
 			MethodName: "UpdateProject",
 			Handler:    _ProjectService_UpdateProject_Handler,
@@ -251,6 +651,50 @@ var ProjectService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteProject",
 			Handler:    _ProjectService_DeleteProject_Handler,
 		},
+		{
+			MethodName: "RestoreProject",
+			Handler:    _ProjectService_RestoreProject_Handler,
+		},
+		{
+			MethodName: "ArchiveProject",
+			Handler:    _ProjectService_ArchiveProject_Handler,
+		},
+		{
+			MethodName: "UnarchiveProject",
+			Handler:    _ProjectService_UnarchiveProject_Handler,
+		},
+		{
+			MethodName: "TransitionProject",
+			Handler:    _ProjectService_TransitionProject_Handler,
+		},
+		{
+			MethodName: "AddProjectMember",
+			Handler:    _ProjectService_AddProjectMember_Handler,
+		},
+		{
+			MethodName: "ListProjectMembers",
+			Handler:    _ProjectService_ListProjectMembers_Handler,
+		},
+		{
+			MethodName: "UpdateProjectMember",
+			Handler:    _ProjectService_UpdateProjectMember_Handler,
+		},
+		{
+			MethodName: "RemoveProjectMember",
+			Handler:    _ProjectService_RemoveProjectMember_Handler,
+		},
+		{
+			MethodName: "ListProjectHistory",
+			Handler:    _ProjectService_ListProjectHistory_Handler,
+		},
+		{
+			MethodName: "GetProjectAtVersion",
+			Handler:    _ProjectService_GetProjectAtVersion_Handler,
+		},
+		{
+			MethodName: "RevertProject",
+			Handler:    _ProjectService_RevertProject_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "project.proto",
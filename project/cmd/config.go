@@ -1,10 +1,222 @@
 package main
 
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix is prepended to the upper-cased flag name to form the environment
+// variable that overrides a setting, e.g. -grpc-port becomes PROJECT_GRPC_PORT.
+const envPrefix = "PROJECT_"
+
 type config struct {
-	API         apiConfig `yaml:"api"`
-	DatabaseURL string    `yaml:"databaseURL"`
+	API         apiConfig         `yaml:"api"`
+	Database    databaseConfig    `yaml:"database"`
+	Consul      consulConfig      `yaml:"consul"`
+	Log         logConfig         `yaml:"log"`
+	Trash       trashConfig       `yaml:"trash"`
+	Idempotency idempotencyConfig `yaml:"idempotency"`
+	Auth        authConfig        `yaml:"auth"`
 }
 
 type apiConfig struct {
-	Port int `yaml:"port"`
+	GRPCPort     int           `yaml:"grpcPort"`
+	HTTPPort     int           `yaml:"httpPort"`
+	ReadTimeout  time.Duration `yaml:"readTimeout"`
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+	IdleTimeout  time.Duration `yaml:"idleTimeout"`
+	DrainTimeout time.Duration `yaml:"drainTimeout"`
+}
+
+type databaseConfig struct {
+	Driver          string        `yaml:"driver"`
+	URL             string        `yaml:"url"`
+	MaxOpenConns    int           `yaml:"maxOpenConns"`
+	MaxIdleConns    int           `yaml:"maxIdleConns"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime"`
+	ConnectTimeout  time.Duration `yaml:"connectTimeout"`
+	AutoMigrate     bool          `yaml:"autoMigrate"`
+}
+
+type consulConfig struct {
+	Address string `yaml:"address"`
+}
+
+type logConfig struct {
+	Level string `yaml:"level"`
+}
+
+type trashConfig struct {
+	Retention     time.Duration `yaml:"retention"`
+	PurgeInterval time.Duration `yaml:"purgeInterval"`
+}
+
+type idempotencyConfig struct {
+	TTL time.Duration `yaml:"ttl"`
+}
+
+type authConfig struct {
+	Issuer        string `yaml:"issuer"`
+	Audience      string `yaml:"audience"`
+	PublicKeyFile string `yaml:"publicKeyFile"`
+}
+
+// bindFlags registers a command-line flag for every configuration setting.
+func (cfg *config) bindFlags(fs *flag.FlagSet) {
+	fs.IntVar(&cfg.API.GRPCPort, "grpc-port", 0, "gRPC server port (api.grpcPort)")
+	fs.IntVar(&cfg.API.HTTPPort, "http-port", 0, "HTTP server port (api.httpPort)")
+	fs.DurationVar(&cfg.API.ReadTimeout, "http-read-timeout", 0, "HTTP server read timeout (api.readTimeout)")
+	fs.DurationVar(&cfg.API.WriteTimeout, "http-write-timeout", 0, "HTTP server write timeout (api.writeTimeout)")
+	fs.DurationVar(&cfg.API.IdleTimeout, "http-idle-timeout", 0, "HTTP server idle timeout (api.idleTimeout)")
+	fs.DurationVar(&cfg.API.DrainTimeout, "drain-timeout", 0, "time allowed for in-flight requests on shutdown (api.drainTimeout)")
+	fs.StringVar(&cfg.Database.Driver, "database-driver", "", "project repository backend: postgres or memory (database.driver)")
+	fs.StringVar(&cfg.Database.URL, "database-url", "", "PostgreSQL DSN (database.url)")
+	fs.IntVar(&cfg.Database.MaxOpenConns, "database-max-open-conns", 0, "maximum open database connections (database.maxOpenConns)")
+	fs.IntVar(&cfg.Database.MaxIdleConns, "database-max-idle-conns", 0, "maximum idle database connections (database.maxIdleConns)")
+	fs.DurationVar(&cfg.Database.ConnMaxLifetime, "database-conn-max-lifetime", 0, "maximum lifetime of a database connection, 0 for unlimited (database.connMaxLifetime)")
+	fs.DurationVar(&cfg.Database.ConnMaxIdleTime, "database-conn-max-idle-time", 0, "maximum idle time of a database connection, 0 for unlimited (database.connMaxIdleTime)")
+	fs.DurationVar(&cfg.Database.ConnectTimeout, "database-connect-timeout", 0, "how long to retry the database connectivity check at startup (database.connectTimeout)")
+	fs.BoolVar(&cfg.Database.AutoMigrate, "database-auto-migrate", false, "apply pending schema migrations on start (database.autoMigrate)")
+	fs.StringVar(&cfg.Consul.Address, "consul-address", "", "Consul agent address (consul.address)")
+	fs.StringVar(&cfg.Log.Level, "log-level", "", "log level: debug, info, warn or error (log.level)")
+	fs.DurationVar(&cfg.Trash.Retention, "trash-retention", 0, "how long trashed projects are kept (trash.retention)")
+	fs.DurationVar(&cfg.Trash.PurgeInterval, "trash-purge-interval", 0, "how often the trash is purged (trash.purgeInterval)")
+	fs.DurationVar(&cfg.Idempotency.TTL, "idempotency-ttl", 0, "how long the response to a request with an idempotency key is kept for retries (idempotency.ttl)")
+	fs.StringVar(&cfg.Auth.Issuer, "auth-issuer", "", "expected token issuer (auth.issuer)")
+	fs.StringVar(&cfg.Auth.Audience, "auth-audience", "", "expected token audience (auth.audience)")
+	fs.StringVar(&cfg.Auth.PublicKeyFile, "auth-public-key-file", "", "PEM file with the token signing public key (auth.publicKeyFile)")
+}
+
+// loadConfig builds the service configuration from, in increasing order of precedence,
+// base.yaml, the optional <env>.yaml for the selected environment, PROJECT_* environment
+// variables and command-line flags, and validates the result. It also returns the
+// arguments remaining after the flags.
+func loadConfig(args []string) (*config, []string, error) {
+	var cfg config
+	fs := flag.NewFlagSet("project", flag.ContinueOnError)
+	env := fs.String("env", os.Getenv(envPrefix+"ENV"), "deployment environment whose <env>.yaml overrides base.yaml")
+	dir := fs.String("config-dir", os.Getenv(envPrefix+"CONFIG_DIR"), "directory containing the configuration files")
+	cfg.bindFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	// Flags are parsed first so -env and -config-dir can be honoured, and re-applied
+	// once the files and environment variables have been loaded.
+	explicit := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = f.Value.String()
+	})
+	configDir := *dir
+	if configDir == "" {
+		configDir = defaultConfigDir()
+	}
+	cfg = config{}
+	if err := decodeFile(filepath.Join(configDir, "base.yaml"), &cfg); err != nil {
+		return nil, nil, err
+	}
+	if *env != "" {
+		if err := decodeFile(filepath.Join(configDir, *env+".yaml"), &cfg); err != nil {
+			return nil, nil, err
+		}
+	}
+	// DATABASE_URL is still honoured for deployments that predate the PROJECT_ prefix.
+	if url, ok := os.LookupEnv("DATABASE_URL"); ok {
+		cfg.Database.URL = url
+	}
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "env" || f.Name == "config-dir" || err != nil {
+			return
+		}
+		name := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		if value, ok := os.LookupEnv(name); ok {
+			if setErr := fs.Set(f.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid value %q for %s: %w", value, name, setErr)
+			}
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, value := range explicit {
+		if err := fs.Set(name, value); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, nil, err
+	}
+	return &cfg, fs.Args(), nil
+}
+
+// decodeFile decodes the YAML file at path into cfg. Settings absent from the
+// file keep their current values.
+func decodeFile(path string, cfg *config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := yaml.NewDecoder(f).Decode(cfg); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// defaultConfigDir locates the configs directory next to the working directory
+// or the executable, so the service does not depend on where it is started from.
+func defaultConfigDir() string {
+	candidates := []string{"configs", filepath.FromSlash("../configs")}
+	if exe, err := os.Executable(); err == nil {
+		candidates = append(candidates, filepath.Join(filepath.Dir(exe), "configs"), filepath.Join(filepath.Dir(exe), "..", "configs"))
+	}
+	for _, dir := range candidates {
+		if _, err := os.Stat(filepath.Join(dir, "base.yaml")); err == nil {
+			return dir
+		}
+	}
+	return candidates[0]
+}
+
+// validate reports every invalid setting in the configuration.
+func (cfg *config) validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	check(cfg.API.GRPCPort > 0 && cfg.API.GRPCPort <= 65535, "api.grpcPort must be between 1 and 65535")
+	check(cfg.API.HTTPPort > 0 && cfg.API.HTTPPort <= 65535, "api.httpPort must be between 1 and 65535")
+	check(cfg.API.GRPCPort != cfg.API.HTTPPort, "api.grpcPort and api.httpPort must differ")
+	check(cfg.API.ReadTimeout > 0, "api.readTimeout must be greater than zero")
+	check(cfg.API.WriteTimeout > 0, "api.writeTimeout must be greater than zero")
+	check(cfg.API.IdleTimeout > 0, "api.idleTimeout must be greater than zero")
+	check(cfg.API.DrainTimeout > 0, "api.drainTimeout must be greater than zero")
+	check(cfg.Database.Driver == "postgres" || cfg.Database.Driver == "memory", "database.driver must be postgres or memory")
+	check(cfg.Database.Driver != "postgres" || cfg.Database.URL != "", "database.url must be provided")
+	check(cfg.Database.MaxOpenConns >= 0, "database.maxOpenConns must not be negative")
+	check(cfg.Database.MaxIdleConns >= 0, "database.maxIdleConns must not be negative")
+	check(cfg.Database.MaxOpenConns == 0 || cfg.Database.MaxIdleConns <= cfg.Database.MaxOpenConns, "database.maxIdleConns must not exceed database.maxOpenConns")
+	check(cfg.Database.ConnMaxLifetime >= 0, "database.connMaxLifetime must not be negative")
+	check(cfg.Database.ConnMaxIdleTime >= 0, "database.connMaxIdleTime must not be negative")
+	check(cfg.Database.ConnectTimeout > 0, "database.connectTimeout must be greater than zero")
+	check(cfg.Consul.Address != "", "consul.address must be provided")
+	_, err := zap.ParseAtomicLevel(cfg.Log.Level)
+	check(err == nil, "log.level %q is not a valid level", cfg.Log.Level)
+	check(cfg.Trash.Retention > 0, "trash.retention must be greater than zero")
+	check(cfg.Trash.PurgeInterval > 0, "trash.purgeInterval must be greater than zero")
+	check(cfg.Idempotency.TTL > 0, "idempotency.ttl must be greater than zero")
+	check(cfg.Auth.Issuer != "", "auth.issuer must be provided")
+	check(cfg.Auth.Audience != "", "auth.audience must be provided")
+	return errors.Join(errs...)
 }
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBaseYAML = `
+api:
+  grpcPort: 8081
+  httpPort: 8082
+  readTimeout: 10s
+  writeTimeout: 30s
+  idleTimeout: 1m
+  drainTimeout: 30s
+database:
+  driver: postgres
+  url: postgres://base
+  maxOpenConns: 25
+  maxIdleConns: 25
+  connectTimeout: 30s
+consul:
+  address: localhost:8500
+log:
+  level: info
+trash:
+  retention: 720h
+  purgeInterval: 1h
+idempotency:
+  ttl: 24h
+auth:
+  issuer: venato
+  audience: project
+`
+
+const testStagingYAML = `
+api:
+  grpcPort: 9081
+log:
+  level: warn
+`
+
+// clearEnv unsets the environment variables read by loadConfig for the
+// duration of the test.
+func clearEnv(t *testing.T) {
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, envPrefix) || name == "DATABASE_URL" {
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "base.yaml"), []byte(testBaseYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "staging.yaml"), []byte(testStagingYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		check    func(cfg *config) bool
+		wantArgs []string
+		wantErr  string
+	}{
+		{
+			name: "base file",
+			check: func(cfg *config) bool {
+				return cfg.API.GRPCPort == 8081 && cfg.Log.Level == "info" && cfg.API.IdleTimeout == time.Minute
+			},
+		},
+		{
+			name: "environment file over base file",
+			args: []string{"-env", "staging"},
+			check: func(cfg *config) bool {
+				return cfg.API.GRPCPort == 9081 && cfg.Log.Level == "warn" && cfg.API.HTTPPort == 8082
+			},
+		},
+		{
+			name:  "environment selected by variable",
+			env:   map[string]string{"PROJECT_ENV": "staging"},
+			check: func(cfg *config) bool { return cfg.API.GRPCPort == 9081 },
+		},
+		{
+			name:  "environment variable over environment file",
+			env:   map[string]string{"PROJECT_ENV": "staging", "PROJECT_GRPC_PORT": "9082"},
+			check: func(cfg *config) bool { return cfg.API.GRPCPort == 9082 && cfg.Log.Level == "warn" },
+		},
+		{
+			name:  "flag over environment variable",
+			env:   map[string]string{"PROJECT_ENV": "staging", "PROJECT_GRPC_PORT": "9082"},
+			args:  []string{"-grpc-port", "9083"},
+			check: func(cfg *config) bool { return cfg.API.GRPCPort == 9083 },
+		},
+		{
+			name:  "flag over environment variable set to the flag default",
+			env:   map[string]string{"PROJECT_DATABASE_AUTO_MIGRATE": "true"},
+			args:  []string{"-database-auto-migrate=false"},
+			check: func(cfg *config) bool { return !cfg.Database.AutoMigrate },
+		},
+		{
+			name: "durations from environment variables",
+			env:  map[string]string{"PROJECT_IDEMPOTENCY_TTL": "2h", "PROJECT_TRASH_RETENTION": "48h"},
+			check: func(cfg *config) bool {
+				return cfg.Idempotency.TTL == 2*time.Hour && cfg.Trash.Retention == 48*time.Hour
+			},
+		},
+		{
+			name:  "legacy database URL over file",
+			env:   map[string]string{"DATABASE_URL": "postgres://legacy"},
+			check: func(cfg *config) bool { return cfg.Database.URL == "postgres://legacy" },
+		},
+		{
+			name:  "prefixed database URL over legacy variable",
+			env:   map[string]string{"DATABASE_URL": "postgres://legacy", "PROJECT_DATABASE_URL": "postgres://prefixed"},
+			check: func(cfg *config) bool { return cfg.Database.URL == "postgres://prefixed" },
+		},
+		{
+			name:  "database URL flag over every variable",
+			env:   map[string]string{"DATABASE_URL": "postgres://legacy", "PROJECT_DATABASE_URL": "postgres://prefixed"},
+			args:  []string{"-database-url", "postgres://flag"},
+			check: func(cfg *config) bool { return cfg.Database.URL == "postgres://flag" },
+		},
+		{
+			name:     "remaining arguments",
+			args:     []string{"-log-level", "debug", "migrate", "up"},
+			check:    func(cfg *config) bool { return cfg.Log.Level == "debug" },
+			wantArgs: []string{"migrate", "up"},
+		},
+		{
+			name:    "invalid environment variable",
+			env:     map[string]string{"PROJECT_GRPC_PORT": "eighty"},
+			wantErr: "PROJECT_GRPC_PORT",
+		},
+		{
+			name:    "invalid flag",
+			args:    []string{"-grpc-port", "eighty"},
+			wantErr: "grpc-port",
+		},
+		{
+			name:    "missing environment file",
+			args:    []string{"-env", "production"},
+			wantErr: "production.yaml",
+		},
+		{
+			name:    "conflicting ports",
+			env:     map[string]string{"PROJECT_HTTP_PORT": "8081", "PROJECT_LOG_LEVEL": "loud"},
+			wantErr: "api.grpcPort and api.httpPort must differ",
+		},
+		{
+			name:    "every invalid setting reported",
+			env:     map[string]string{"PROJECT_HTTP_PORT": "8081", "PROJECT_LOG_LEVEL": "loud"},
+			wantErr: `log.level "loud" is not a valid level`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("PROJECT_CONFIG_DIR", dir)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			cfg, args, err := loadConfig(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want an error mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadConfig: %v", err)
+			}
+			if !tt.check(cfg) {
+				t.Errorf("got configuration %+v", cfg)
+			}
+			if strings.Join(args, " ") != strings.Join(tt.wantArgs, " ") {
+				t.Errorf("got arguments %q, want %q", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -2,72 +2,325 @@ package main
 
 import (
 	"context"
+	"errors"
+	"expvar"
+	"flag"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"runtime"
+	"syscall"
 	"time"
 
 	"github.com/emzola/venato/gen"
 	"github.com/emzola/venato/pkg/discovery"
 	"github.com/emzola/venato/pkg/discovery/consul"
+	"github.com/emzola/venato/project/internal/auth"
+	"github.com/emzola/venato/project/internal/authz"
 	"github.com/emzola/venato/project/internal/controller/project"
 	grpcHandler "github.com/emzola/venato/project/internal/handler/grpc"
+	httpHandler "github.com/emzola/venato/project/internal/handler/http"
+	"github.com/emzola/venato/project/internal/migrate"
+	"github.com/emzola/venato/project/internal/repository/memory"
 	"github.com/emzola/venato/project/internal/repository/postgresql"
+	"github.com/emzola/venato/project/migrations"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
-	"gopkg.in/yaml.v3"
 )
 
-var serviceName = "Project"
+var (
+	serviceName     = "Project"
+	httpServiceName = "Project-HTTP"
+)
 
 func main() {
-	logger, _ := zap.NewProduction()
+	cfg, args, err := loadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", err)
+		os.Exit(2)
+	}
+	if len(args) > 0 {
+		if args[0] != "migrate" {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+			os.Exit(2)
+		}
+		if err := runMigrate(cfg, args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	logger, err := newLogger(cfg.Log.Level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create logger: %v\n", err)
+		os.Exit(1)
+	}
 	defer logger.Sync()
-	f, err := os.Open(filepath.FromSlash("../configs/base.yaml"))
+	if err := run(cfg, logger); err != nil {
+		logger.Error("Project service stopped unexpectedly", zap.Error(err))
+		logger.Sync()
+		os.Exit(1)
+	}
+	logger.Info("Project service stopped")
+}
+
+// run starts the project service and blocks until it receives a termination signal
+// or either server fails. The service is only registered with Consul once both
+// servers accept connections, and every resource acquired is released on return.
+func run(cfg *config, logger *zap.Logger) error {
+	grpcPort, httpPort := cfg.API.GRPCPort, cfg.API.HTTPPort
+	logger.Info("Starting the project service", zap.Int("grpcPort", grpcPort), zap.Int("httpPort", httpPort))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	verifier, err := newVerifier(cfg.Auth)
 	if err != nil {
-		logger.Fatal("Failed to open configuration", zap.Error(err))
+		return fmt.Errorf("configure token verification: %w", err)
 	}
-	var cfg config
-	if err := yaml.NewDecoder(f).Decode(&cfg); err != nil {
-		logger.Fatal("Failed to parse configuration", zap.Error(err))
+	ctrl, closeRepo, err := newController(ctx, cfg, logger)
+	if err != nil {
+		return fmt.Errorf("set up the project repository: %w", err)
 	}
-	port := cfg.API.Port
-	logger.Info("Starting the project service", zap.Int("port", port))
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	registry, err := consul.NewRegistry("localhost:8500")
+	defer func() {
+		if err := closeRepo(); err != nil {
+			logger.Error("Failed to close database connection pool", zap.Error(err))
+		}
+	}()
+	expvar.Publish("goroutines", expvar.Func(func() interface{} {
+		return runtime.NumGoroutine()
+	}))
+	lis, err := net.Listen("tcp", fmt.Sprintf("localhost:%v", grpcPort))
+	if err != nil {
+		return fmt.Errorf("listen for gRPC: %w", err)
+	}
+	httpLis, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", httpPort))
+	if err != nil {
+		lis.Close()
+		return fmt.Errorf("listen for HTTP: %w", err)
+	}
+	// The purge loop is stopped and waited for before the repository is closed.
+	purgeCtx, stopPurge := context.WithCancel(ctx)
+	purgeDone := make(chan struct{})
+	go func() {
+		defer close(purgeDone)
+		purge(purgeCtx, ctrl, cfg, logger)
+	}()
+	defer func() {
+		stopPurge()
+		<-purgeDone
+	}()
+	srv := grpc.NewServer(grpc.UnaryInterceptor(grpcHandler.AuthInterceptor(verifier)))
+	reflection.Register(srv)
+	gen.RegisterProjectServiceServer(srv, grpcHandler.New(ctrl))
+	httpSrv := &http.Server{
+		Handler:      httpHandler.New(ctrl, verifier).Routes(),
+		ErrorLog:     zap.NewStdLog(logger),
+		IdleTimeout:  cfg.API.IdleTimeout,
+		ReadTimeout:  cfg.API.ReadTimeout,
+		WriteTimeout: cfg.API.WriteTimeout,
+	}
+	// Both servers share the controller and are shut down together on a
+	// termination signal or as soon as either one fails.
+	errCh := make(chan error, 2)
+	go func() {
+		errCh <- srv.Serve(lis)
+	}()
+	go func() {
+		errCh <- httpSrv.Serve(httpLis)
+	}()
+	defer func() {
+		if err := shutdown(srv, httpSrv, cfg.API.DrainTimeout); err != nil {
+			logger.Error("Failed to drain in-flight requests", zap.Error(err))
+		}
+	}()
+	// Deferred calls run in reverse, so the instance is deregistered before the
+	// servers drain and no new traffic is routed to it in the meantime.
+	deregister, err := register(ctx, cfg.Consul.Address, grpcPort, httpPort, logger)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("register the service: %w", err)
+	}
+	defer deregister()
+	select {
+	case <-ctx.Done():
+		logger.Info("Shutting down the project service")
+	case err = <-errCh:
+		err = fmt.Errorf("server stopped: %w", err)
+	}
+	stop()
+	return err
+}
+
+// register registers the gRPC and HTTP endpoints of the service instance with Consul
+// and reports them healthy until the returned function deregisters them.
+func register(ctx context.Context, address string, grpcPort, httpPort int, logger *zap.Logger) (func(), error) {
+	registry, err := consul.NewRegistry(address)
+	if err != nil {
+		return nil, err
 	}
 	instanceID := discovery.GenerateInstanceID(serviceName)
-	if err := registry.Register(ctx, instanceID, serviceName, fmt.Sprintf("localhost:%d", port)); err != nil {
-		panic(err)
+	if err := registry.Register(ctx, instanceID, serviceName, fmt.Sprintf("localhost:%d", grpcPort)); err != nil {
+		return nil, err
 	}
+	httpInstanceID := discovery.GenerateInstanceID(httpServiceName)
+	if err := registry.Register(ctx, httpInstanceID, httpServiceName, fmt.Sprintf("localhost:%d", httpPort)); err != nil {
+		registry.Deregister(context.Background(), instanceID, serviceName)
+		return nil, err
+	}
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	healthDone := make(chan struct{})
 	go func() {
+		defer close(healthDone)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
 		for {
 			if err := registry.ReportHealthyState(instanceID, serviceName); err != nil {
 				logger.Error("Failed to report healthy state", zap.Error(err))
 			}
-			time.Sleep(time.Second)
+			if err := registry.ReportHealthyState(httpInstanceID, httpServiceName); err != nil {
+				logger.Error("Failed to report healthy state", zap.Error(err))
+			}
+			select {
+			case <-healthCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		stopHealth()
+		<-healthDone
+		if err := registry.Deregister(context.Background(), instanceID, serviceName); err != nil {
+			logger.Error("Failed to deregister service", zap.String("serviceName", serviceName), zap.Error(err))
+		}
+		if err := registry.Deregister(context.Background(), httpInstanceID, httpServiceName); err != nil {
+			logger.Error("Failed to deregister service", zap.String("serviceName", httpServiceName), zap.Error(err))
+		}
+	}, nil
+}
+
+// purge periodically removes trashed projects past their retention period and
+// expired idempotency keys, until ctx is done.
+func purge(ctx context.Context, ctrl *project.Controller, cfg *config, logger *zap.Logger) {
+	ticker := time.NewTicker(cfg.Trash.PurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		purged, err := ctrl.PurgeTrash(ctx, cfg.Trash.Retention)
+		if err != nil {
+			logger.Error("Failed to purge trashed projects", zap.Error(err))
+		} else if purged > 0 {
+			logger.Info("Purged trashed projects", zap.Int64("count", purged))
 		}
+		expired, err := ctrl.PurgeIdempotencyKeys(ctx)
+		if err != nil {
+			logger.Error("Failed to purge expired idempotency keys", zap.Error(err))
+		} else if expired > 0 {
+			logger.Info("Purged expired idempotency keys", zap.Int64("count", expired))
+		}
+	}
+}
+
+// shutdown gracefully stops both servers, waiting up to timeout for in-flight
+// requests to complete before closing any remaining connections.
+func shutdown(srv *grpc.Server, httpSrv *http.Server, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
 	}()
-	defer registry.Deregister(ctx, instanceID, serviceName)
-	repo, err := postgresql.New()
+	err := httpSrv.Shutdown(ctx)
 	if err != nil {
-		logger.Fatal("Failed to establish database connection pool", zap.Error(err))
+		httpSrv.Close()
+	}
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		srv.Stop()
+		if err == nil {
+			err = ctx.Err()
+		}
 	}
-	ctrl := project.New(repo)
-	h := grpcHandler.New(ctrl)
-	lis, err := net.Listen("tcp", fmt.Sprintf("localhost:%v", port))
+	return err
+}
+
+// newController creates the project controller on top of the configured repository
+// backend. It also returns a function releasing the resources held by the repository.
+func newController(ctx context.Context, cfg *config, logger *zap.Logger) (*project.Controller, func() error, error) {
+	if cfg.Database.Driver == "memory" {
+		logger.Warn("Using the in-memory project repository, data will be lost on exit")
+		repo := memory.New()
+		return project.New(repo, authz.NewRBAC(repo), cfg.Idempotency.TTL), func() error { return nil }, nil
+	}
+	db, err := postgresql.Open(ctx, postgresConfig(cfg.Database))
 	if err != nil {
-		logger.Fatal("Failed to listen", zap.Error(err))
+		return nil, nil, err
 	}
-	srv := grpc.NewServer()
-	reflection.Register(srv)
-	gen.RegisterProjectServiceServer(srv, h)
-	if err := srv.Serve(lis); err != nil {
-		panic(err)
+	if cfg.Database.AutoMigrate {
+		migrator, err := migrate.New(db, migrations.FS)
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		applied, err := migrator.Up(ctx, 0)
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		logger.Info("Applied schema migrations", zap.Int("count", applied))
+	}
+	repo := postgresql.New(db)
+	expvar.Publish("database", expvar.Func(func() interface{} {
+		return repo.Stats()
+	}))
+	return project.New(repo, authz.NewRBAC(repo), cfg.Idempotency.TTL), repo.Close, nil
+}
+
+// postgresConfig converts the database settings into a PostgreSQL repository configuration.
+func postgresConfig(cfg databaseConfig) postgresql.Config {
+	return postgresql.Config{
+		URL:             cfg.URL,
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+		ConnectTimeout:  cfg.ConnectTimeout,
+	}
+}
+
+// newLogger creates a production logger that writes entries at or above level.
+func newLogger(level string) (*zap.Logger, error) {
+	lvl, err := zap.ParseAtomicLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	zapCfg := zap.NewProductionConfig()
+	zapCfg.Level = lvl
+	return zapCfg.Build()
+}
+
+// newVerifier creates a token verifier from the configured public key file or,
+// when none is configured, from the HMAC secret in the AUTH_HMAC_SECRET environment variable.
+func newVerifier(cfg authConfig) (*auth.Verifier, error) {
+	if cfg.PublicKeyFile != "" {
+		data, err := os.ReadFile(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err := auth.ParsePublicKeyPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		return auth.NewVerifier(key, cfg.Issuer, cfg.Audience)
 	}
+	return auth.NewVerifier([]byte(os.Getenv("AUTH_HMAC_SECRET")), cfg.Issuer, cfg.Audience)
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/emzola/venato/project/internal/migrate"
+	"github.com/emzola/venato/project/internal/repository/postgresql"
+	"github.com/emzola/venato/project/migrations"
+)
+
+const migrateUsage = "usage: migrate up [N] | down [N] | status | force VERSION"
+
+// runMigrate executes the migrate subcommand against the configured database.
+func runMigrate(cfg *config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	if cfg.Database.Driver != "postgres" {
+		return errors.New("migrations require the postgres database driver")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	db, err := postgresql.Open(ctx, postgresConfig(cfg.Database))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+	switch command, rest := args[0], args[1:]; command {
+	case "up", "down":
+		// up applies every pending migration by default, while down reverts
+		// a single migration unless told otherwise.
+		n := 0
+		if command == "down" {
+			n = 1
+		}
+		if len(rest) > 0 {
+			if n, err = strconv.Atoi(rest[0]); err != nil || n < 1 {
+				return fmt.Errorf("invalid migration count %q", rest[0])
+			}
+		}
+		if command == "up" {
+			n, err = migrator.Up(ctx, n)
+			fmt.Printf("applied %d migration(s)\n", n)
+		} else {
+			n, err = migrator.Down(ctx, n)
+			fmt.Printf("reverted %d migration(s)\n", n)
+		}
+		return err
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d", status.Version)
+		if status.Dirty {
+			fmt.Print(" (dirty)")
+		}
+		fmt.Println()
+		for _, m := range status.Migrations {
+			state := "pending"
+			if m.Version <= status.Version {
+				state = "applied"
+			}
+			fmt.Printf("%06d_%s\t%s\n", m.Version, m.Name, state)
+		}
+		return nil
+	case "force":
+		if len(rest) != 1 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.ParseInt(rest[0], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid migration version %q", rest[0])
+		}
+		return migrator.Force(ctx, version)
+	default:
+		return errors.New(migrateUsage)
+	}
+}
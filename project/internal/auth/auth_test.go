@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	secret      = []byte("0123456789abcdef0123456789abcdef")
+	otherSecret = []byte("fedcba9876543210fedcba9876543210")
+)
+
+// sign returns a token for the claims signed with the method and key.
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, c jwt.Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, c).SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return token
+}
+
+// validClaims returns claims accepted by a verifier for the issuer "venato" and audience "project".
+func validClaims() claims {
+	return claims{
+		Roles: []string{"admin"},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "42",
+			Issuer:    "venato",
+			Audience:  jwt.ClaimStrings{"project"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hmacVerifier, err := NewVerifier(secret, "venato", "project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaVerifier, err := NewVerifier(&rsaKey.PublicKey, "venato", "project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecVerifier, err := NewVerifier(&ecKey.PublicKey, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	with := func(modify func(c *claims)) claims {
+		c := validClaims()
+		modify(&c)
+		return c
+	}
+	valid := validClaims()
+	// Swapping in the claims of another token leaves a signature which no longer matches them.
+	token := strings.Split(sign(t, jwt.SigningMethodHS256, secret, valid), ".")
+	other := strings.Split(sign(t, jwt.SigningMethodHS256, secret, with(func(c *claims) { c.Subject = "1" })), ".")
+	tampered := strings.Join([]string{token[0], other[1], token[2]}, ".")
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, valid).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		token    string
+		wantID   int64
+	}{
+		{"valid HMAC token", hmacVerifier, sign(t, jwt.SigningMethodHS256, secret, valid), 42},
+		{"valid HS512 token", hmacVerifier, sign(t, jwt.SigningMethodHS512, secret, valid), 42},
+		{"valid RSA token", rsaVerifier, sign(t, jwt.SigningMethodRS256, rsaKey, valid), 42},
+		{"valid ECDSA token", ecVerifier, sign(t, jwt.SigningMethodES256, ecKey, valid), 42},
+		{"expired", hmacVerifier, sign(t, jwt.SigningMethodHS256, secret, with(func(c *claims) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		})), 0},
+		{"without expiry", hmacVerifier, sign(t, jwt.SigningMethodHS256, secret, with(func(c *claims) { c.ExpiresAt = nil })), 0},
+		{"not valid yet", hmacVerifier, sign(t, jwt.SigningMethodHS256, secret, with(func(c *claims) {
+			c.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Hour))
+		})), 0},
+		{"bad signature", hmacVerifier, sign(t, jwt.SigningMethodHS256, otherSecret, valid), 0},
+		{"tampered claims", hmacVerifier, tampered, 0},
+		{"unsigned", hmacVerifier, unsigned, 0},
+		{"RSA token for an HMAC verifier", hmacVerifier, sign(t, jwt.SigningMethodRS256, rsaKey, valid), 0},
+		{"HMAC token for an RSA verifier", rsaVerifier, sign(t, jwt.SigningMethodHS256, secret, valid), 0},
+		{"ECDSA token for an RSA verifier", rsaVerifier, sign(t, jwt.SigningMethodES256, ecKey, valid), 0},
+		{"wrong issuer", hmacVerifier, sign(t, jwt.SigningMethodHS256, secret, with(func(c *claims) { c.Issuer = "other" })), 0},
+		{"wrong audience", hmacVerifier, sign(t, jwt.SigningMethodHS256, secret, with(func(c *claims) {
+			c.Audience = jwt.ClaimStrings{"report"}
+		})), 0},
+		{"non-numeric subject", hmacVerifier, sign(t, jwt.SigningMethodHS256, secret, with(func(c *claims) { c.Subject = "alice" })), 0},
+		{"zero subject", hmacVerifier, sign(t, jwt.SigningMethodHS256, secret, with(func(c *claims) { c.Subject = "0" })), 0},
+		{"malformed", hmacVerifier, "not.a.token", 0},
+		{"empty", hmacVerifier, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := tt.verifier.Verify(tt.token)
+			if tt.wantID == 0 {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("got principal %+v, error %v; want ErrInvalidToken", principal, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if principal.UserID != tt.wantID || !principal.HasRole("admin") {
+				t.Errorf("got principal %+v, want user %d with the admin role", principal, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     interface{}
+		wantErr bool
+	}{
+		{"HMAC secret", secret, false},
+		{"short HMAC secret", []byte("too short"), true},
+		{"string secret", string(secret), true},
+		{"nil key", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewVerifier(tt.key, "", "")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
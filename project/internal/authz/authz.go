@@ -0,0 +1,94 @@
+// Package authz decides which project operations a caller may perform.
+package authz
+
+import (
+	"context"
+	"errors"
+
+	"github.com/emzola/venato/project/internal/auth"
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/emzola/venato/project/pkg/model"
+)
+
+// ErrPermissionDenied is returned when a principal may not perform an action.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// AdminRole is the global role whose holders may perform any action on any project.
+const AdminRole = "admin"
+
+// Action defines an operation on a project.
+type Action string
+
+// Supported project actions.
+const (
+	ActionCreate        Action = "project:create"
+	ActionListAll       Action = "project:list_all" // list every project rather than only those the principal is a member of.
+	ActionRead          Action = "project:read"
+	ActionUpdate        Action = "project:update"
+	ActionArchive       Action = "project:archive"
+	ActionDelete        Action = "project:delete"
+	ActionRestore       Action = "project:restore"
+	ActionManageMembers Action = "project:manage_members"
+	ActionManageOwners  Action = "project:manage_owners" // grant or revoke the owner role.
+)
+
+// Policy decides whether a principal may perform an action on a project.
+// The project id is zero for actions which do not target an existing project.
+type Policy interface {
+	Authorize(ctx context.Context, principal *auth.Principal, action Action, projectID int64) error
+}
+
+type memberRepository interface {
+	GetMember(ctx context.Context, projectID, userID int64) (*model.Member, error)
+}
+
+// permissions holds the actions each project role may perform.
+var permissions = map[model.Role][]Action{
+	model.RoleOwner:  {ActionRead, ActionUpdate, ActionArchive, ActionDelete, ActionRestore, ActionManageMembers, ActionManageOwners},
+	model.RoleAdmin:  {ActionRead, ActionUpdate, ActionArchive, ActionManageMembers},
+	model.RoleMember: {ActionRead, ActionUpdate},
+	model.RoleViewer: {ActionRead},
+}
+
+// RBAC defines a role-based Policy. Holders of the global AdminRole may do
+// anything, any authenticated principal may create projects, and every other
+// action is granted by the principal's role within the project.
+type RBAC struct {
+	members memberRepository
+}
+
+// NewRBAC creates a new role-based policy which looks up project roles in the given repository.
+func NewRBAC(members memberRepository) *RBAC {
+	return &RBAC{members}
+}
+
+// Authorize returns ErrPermissionDenied unless the principal may perform the action on the project.
+func (p *RBAC) Authorize(ctx context.Context, principal *auth.Principal, action Action, projectID int64) error {
+	if principal == nil {
+		return ErrPermissionDenied
+	}
+	if principal.HasRole(AdminRole) {
+		return nil
+	}
+	if action == ActionCreate {
+		return nil
+	}
+	if projectID < 1 {
+		return ErrPermissionDenied
+	}
+	member, err := p.members.GetMember(ctx, projectID, principal.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return ErrPermissionDenied
+		default:
+			return err
+		}
+	}
+	for _, allowed := range permissions[member.Role] {
+		if allowed == action {
+			return nil
+		}
+	}
+	return ErrPermissionDenied
+}
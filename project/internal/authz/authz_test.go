@@ -0,0 +1,103 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/emzola/venato/project/internal/auth"
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/emzola/venato/project/pkg/model"
+)
+
+// members is a memberRepository holding the role of each user in project 1.
+type members map[int64]model.Role
+
+func (m members) GetMember(ctx context.Context, projectID, userID int64) (*model.Member, error) {
+	role, ok := m[userID]
+	if !ok || projectID != 1 {
+		return nil, repository.ErrNotFound
+	}
+	return &model.Member{ProjectID: projectID, UserID: userID, Role: role}, nil
+}
+
+// failingMembers is a memberRepository which cannot be reached.
+type failingMembers struct{}
+
+var errUnavailable = errors.New("unavailable")
+
+func (failingMembers) GetMember(ctx context.Context, projectID, userID int64) (*model.Member, error) {
+	return nil, errUnavailable
+}
+
+func TestAuthorizeProjectRoles(t *testing.T) {
+	policy := NewRBAC(members{
+		1: model.RoleOwner,
+		2: model.RoleAdmin,
+		3: model.RoleMember,
+		4: model.RoleViewer,
+	})
+	users := map[model.Role]int64{model.RoleOwner: 1, model.RoleAdmin: 2, model.RoleMember: 3, model.RoleViewer: 4}
+	tests := []struct {
+		action  Action
+		allowed []model.Role
+	}{
+		{ActionCreate, []model.Role{model.RoleOwner, model.RoleAdmin, model.RoleMember, model.RoleViewer}},
+		{ActionListAll, nil},
+		{ActionRead, []model.Role{model.RoleOwner, model.RoleAdmin, model.RoleMember, model.RoleViewer}},
+		{ActionUpdate, []model.Role{model.RoleOwner, model.RoleAdmin, model.RoleMember}},
+		{ActionArchive, []model.Role{model.RoleOwner, model.RoleAdmin}},
+		{ActionDelete, []model.Role{model.RoleOwner}},
+		{ActionRestore, []model.Role{model.RoleOwner}},
+		{ActionManageMembers, []model.Role{model.RoleOwner, model.RoleAdmin}},
+		{ActionManageOwners, []model.Role{model.RoleOwner}},
+	}
+	for _, tt := range tests {
+		for role, userID := range users {
+			t.Run(string(tt.action)+"/"+string(role), func(t *testing.T) {
+				want := false
+				for _, allowed := range tt.allowed {
+					want = want || allowed == role
+				}
+				err := policy.Authorize(context.Background(), &auth.Principal{UserID: userID}, tt.action, 1)
+				switch {
+				case want && err != nil:
+					t.Errorf("got error %v, want the action allowed", err)
+				case !want && !errors.Is(err, ErrPermissionDenied):
+					t.Errorf("got error %v, want ErrPermissionDenied", err)
+				}
+			})
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	policy := NewRBAC(members{1: model.RoleOwner})
+	tests := []struct {
+		name      string
+		policy    *RBAC
+		principal *auth.Principal
+		action    Action
+		projectID int64
+		wantErr   error
+	}{
+		{"unauthenticated", policy, nil, ActionRead, 1, ErrPermissionDenied},
+		{"unauthenticated create", policy, nil, ActionCreate, 0, ErrPermissionDenied},
+		{"global admin", policy, &auth.Principal{UserID: 9, Roles: []string{AdminRole}}, ActionDelete, 1, nil},
+		{"global admin listing all", policy, &auth.Principal{UserID: 9, Roles: []string{AdminRole}}, ActionListAll, 0, nil},
+		{"other global role", policy, &auth.Principal{UserID: 9, Roles: []string{"auditor"}}, ActionRead, 1, ErrPermissionDenied},
+		{"non-member", policy, &auth.Principal{UserID: 9}, ActionRead, 1, ErrPermissionDenied},
+		{"owner of another project", policy, &auth.Principal{UserID: 1}, ActionRead, 2, ErrPermissionDenied},
+		{"owner without a project", policy, &auth.Principal{UserID: 1}, ActionRead, 0, ErrPermissionDenied},
+		{"create without a project", policy, &auth.Principal{UserID: 9}, ActionCreate, 0, nil},
+		{"repository failure", NewRBAC(failingMembers{}), &auth.Principal{UserID: 1}, ActionRead, 1, errUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Authorize(context.Background(), tt.principal, tt.action, tt.projectID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
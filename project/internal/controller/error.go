@@ -3,7 +3,10 @@ package controller
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+
+	"github.com/emzola/venato/project/pkg/model"
 )
 
 var (
@@ -13,15 +16,72 @@ var (
 	ErrFailedValidation = errors.New("failed validation")
 	// ErrEditConflict is returned when there is an edit conflict error.
 	ErrEditConflict = errors.New("edit conflict")
+	// ErrProjectArchived is returned when attempting to modify an archived project.
+	ErrProjectArchived = errors.New("project archived")
+	// ErrProjectNotArchived is returned when attempting to unarchive a project which is not archived.
+	ErrProjectNotArchived = errors.New("project not archived")
+	// ErrInvalidTransition is returned when a project status transition is not allowed.
+	ErrInvalidTransition = errors.New("invalid status transition")
+	// ErrDuplicateMember is returned when a user is already a member of a project.
+	ErrDuplicateMember = errors.New("duplicate member")
+	// ErrLastOwner is returned when a change would leave a project without an owner.
+	ErrLastOwner = errors.New("last owner")
+	// ErrPermissionDenied is returned when the caller may not perform an operation.
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrConflict is returned when a change conflicts with other stored data.
+	ErrConflict = errors.New("conflict")
+	// ErrVersionMismatch is returned when a project is no longer at the version a client expects.
+	ErrVersionMismatch = errors.New("version mismatch")
+	// ErrIdempotencyKeyReused is returned when an idempotency key is sent again with a different request.
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused")
+	// ErrSnapshotUnavailable is returned when a project version was recorded before snapshots were kept.
+	ErrSnapshotUnavailable = errors.New("snapshot unavailable")
+	// ErrTimeout is returned when the data store gives up on an operation before it completes.
+	ErrTimeout = errors.New("timeout")
 )
 
-// FailedValidation loops through a validation error map and
-// returns an error string with the key and value of the error map.
-func FailedValidation(errorMap map[string]string) error {
+// TransitionError records a project status transition which is not allowed.
+type TransitionError struct {
+	From model.ProjectStatus
+	To   model.ProjectStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot transition project from %s to %s", e.From, e.To)
+}
+
+// Unwrap allows TransitionError to be matched against ErrInvalidTransition.
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// ValidationError records the fields which failed validation and why.
+type ValidationError struct {
+	Errors map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Errors))
+	for field := range e.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
 	var s strings.Builder
-	for k, v := range errorMap {
-		s.WriteString(fmt.Sprintf("%s: %s; ", k, v))
+	for i, field := range fields {
+		if i > 0 {
+			s.WriteString("; ")
+		}
+		fmt.Fprintf(&s, "%s: %s", field, e.Errors[field])
 	}
-	err := fmt.Errorf("%s", s.String())
-	return err
+	return s.String()
+}
+
+// Is allows ValidationError to be matched against ErrFailedValidation.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrFailedValidation
+}
+
+// FailedValidation returns a ValidationError for a validation error map.
+func FailedValidation(errorMap map[string]string) error {
+	return &ValidationError{Errors: errorMap}
 }
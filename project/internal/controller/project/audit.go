@@ -0,0 +1,168 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/emzola/venato/project/internal/authz"
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/emzola/venato/project/pkg/model"
+	"github.com/emzola/venato/project/pkg/validator"
+)
+
+// historySortSafelist holds the supported sort values for project histories.
+var historySortSafelist = []string{"version", "-version"}
+
+// History retrieves a paginated list of the changes made to a project.
+func (c *Controller) History(ctx context.Context, id int64, filters model.Filters) ([]*model.AuditEntry, model.Metadata, error) {
+	if err := c.authorize(ctx, authz.ActionRead, id); err != nil {
+		return nil, model.Metadata{}, err
+	}
+	filters.SortSafelist = historySortSafelist
+	v := validator.New()
+	if model.ValidateFilters(v, filters); !v.Valid() {
+		return nil, model.Metadata{}, controller.FailedValidation(v.Errors)
+	}
+	entries, metadata, err := c.repo.GetHistory(ctx, id, filters)
+	if err != nil {
+		return nil, model.Metadata{}, translateError(err)
+	}
+	// The history of a project outlives the project, so an empty page
+	// only means the project is missing if it is not stored either.
+	if len(entries) == 0 {
+		if err := c.checkExists(ctx, id); err != nil {
+			return nil, model.Metadata{}, err
+		}
+	}
+	return entries, metadata, nil
+}
+
+// GetAtVersion retrieves a project as it was at the given version.
+func (c *Controller) GetAtVersion(ctx context.Context, id, version int64) (*model.Project, error) {
+	if err := c.authorize(ctx, authz.ActionRead, id); err != nil {
+		return nil, err
+	}
+	return snapshot(c.repo.GetAuditEntry(ctx, id, version))
+}
+
+// GetAt retrieves a project as it was at the given time.
+func (c *Controller) GetAt(ctx context.Context, id int64, at time.Time) (*model.Project, error) {
+	if err := c.authorize(ctx, authz.ActionRead, id); err != nil {
+		return nil, err
+	}
+	return snapshot(c.repo.GetAuditEntryAt(ctx, id, at))
+}
+
+// Revert gives a project back the name, description and dates it had at an earlier
+// version, as a new version of the project. Its status, archiving and trash state are
+// changed through their own operations instead. If expectedVersion is not zero,
+// ErrVersionMismatch is returned unless the project is still at that version.
+// Reverting to the details the project already has returns it as it is, without writing it.
+func (c *Controller) Revert(ctx context.Context, id, version, expectedVersion int64, modifiedBy int64) (*model.Project, error) {
+	if err := c.authorize(ctx, authz.ActionUpdate, id); err != nil {
+		return nil, err
+	}
+	project, err := c.repo.Get(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, controller.ErrNotFound
+		default:
+			return nil, translateError(err)
+		}
+	}
+	if expectedVersion != 0 && project.Version != expectedVersion {
+		return nil, controller.ErrVersionMismatch
+	}
+	if project.ArchivedOn != nil {
+		return nil, controller.ErrProjectArchived
+	}
+	target, err := snapshot(c.repo.GetAuditEntry(ctx, id, version))
+	if err != nil {
+		return nil, err
+	}
+	before := *project
+	project.Name = target.Name
+	project.Description = target.Description
+	project.StartDate = target.StartDate
+	project.TargetEndDate = target.TargetEndDate
+	if len(model.DiffProjects(&before, project)) == 0 {
+		return &before, nil
+	}
+	project.ModifiedBy = modifiedBy
+	v := validator.New()
+	if model.ValidateProject(v, project); !v.Valid() {
+		return nil, controller.FailedValidation(v.Errors)
+	}
+	err = c.update(ctx, model.AuditRevert, &before, project)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, controller.ErrEditConflict
+		default:
+			return nil, translateError(err)
+		}
+	}
+	return project, nil
+}
+
+// snapshot returns the project recorded by an audit entry. It returns ErrNotFound if
+// there is no entry, and ErrSnapshotUnavailable if the entry was recorded before
+// snapshots were kept.
+func snapshot(entry *model.AuditEntry, err error) (*model.Project, error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, controller.ErrNotFound
+		default:
+			return nil, translateError(err)
+		}
+	}
+	if entry.Snapshot == nil {
+		return nil, controller.ErrSnapshotUnavailable
+	}
+	return entry.Snapshot, nil
+}
+
+// checkExists returns ErrNotFound unless a project is stored, whether or not it is in the trash.
+func (c *Controller) checkExists(ctx context.Context, id int64) error {
+	_, err := c.repo.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		_, err = c.repo.GetTrashed(ctx, id)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return controller.ErrNotFound
+		default:
+			return translateError(err)
+		}
+	}
+	return nil
+}
+
+// audit records a change to a project, together with a snapshot of the project
+// the change produced, in its audit trail. before is nil when the project was created.
+func (c *Controller) audit(ctx context.Context, action model.AuditAction, before, after *model.Project, actor int64) error {
+	recorded := *after
+	return c.repo.CreateAuditEntry(ctx, &model.AuditEntry{
+		ProjectID: after.ID,
+		Version:   after.Version,
+		Action:    action,
+		Actor:     actor,
+		Changes:   model.DiffProjects(before, after),
+		Snapshot:  &recorded,
+	})
+}
+
+// update stores the changes made to a project and records them in its audit trail, in one transaction.
+func (c *Controller) update(ctx context.Context, action model.AuditAction, before, project *model.Project) error {
+	return c.repo.RunInTx(ctx, func(ctx context.Context) error {
+		if err := c.repo.Update(ctx, project); err != nil {
+			return err
+		}
+		return c.audit(ctx, action, before, project, project.ModifiedBy)
+	})
+}
@@ -0,0 +1,271 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/emzola/venato/project/internal/authz"
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/internal/repository/memory"
+	"github.com/emzola/venato/project/pkg/model"
+)
+
+func TestHistory(t *testing.T) {
+	c := newTestController(t)
+	project := mustCreate(t, c, "Apollo")
+	name, description := "Apollo 11", "Moon landing"
+	// Each step changes the project and names the audit entry it should record.
+	steps := []struct {
+		name       string
+		fn         func() error
+		wantAction model.AuditAction
+		wantFields []string
+	}{
+		{"update", func() error {
+			_, err := c.Update(as(1), project.ID, 0, &name, &description, nil, nil, 1)
+			return err
+		}, model.AuditUpdate, []string{"description", "name"}},
+		{"update without changes", func() error {
+			_, err := c.Update(as(1), project.ID, 0, &name, nil, nil, nil, 1)
+			return err
+		}, "", nil},
+		{"transition", func() error {
+			_, err := c.Transition(as(1), project.ID, model.StatusActive, nil, 1)
+			return err
+		}, model.AuditTransition, []string{"status"}},
+		{"archive", func() error {
+			_, err := c.Archive(as(1), project.ID, 1)
+			return err
+		}, model.AuditArchive, []string{"archived_by", "archived_on"}},
+		{"unarchive", func() error {
+			_, err := c.Unarchive(as(1), project.ID, 1)
+			return err
+		}, model.AuditUnarchive, []string{"archived_by", "archived_on"}},
+		{"delete", func() error {
+			return c.Delete(as(1), project.ID, 0, 1)
+		}, model.AuditDelete, []string{"deleted_by", "deleted_on"}},
+		{"restore", func() error {
+			_, err := c.Restore(as(1), project.ID, 1)
+			return err
+		}, model.AuditRestore, []string{"deleted_by", "deleted_on"}},
+	}
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "-version"}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			before, _, err := c.History(as(1), project.ID, filters)
+			if err != nil {
+				t.Fatalf("History: %v", err)
+			}
+			if err := step.fn(); err != nil {
+				t.Fatalf("%s: %v", step.name, err)
+			}
+			entries, _, err := c.History(as(1), project.ID, filters)
+			if err != nil {
+				t.Fatalf("History: %v", err)
+			}
+			if step.wantAction == "" {
+				if len(entries) != len(before) {
+					t.Errorf("got %d audit entries, want %d", len(entries), len(before))
+				}
+				return
+			}
+			if len(entries) != len(before)+1 {
+				t.Fatalf("got %d audit entries, want %d", len(entries), len(before)+1)
+			}
+			entry := entries[0]
+			if entry.Action != step.wantAction || entry.Version != before[0].Version+1 || entry.Actor != 1 {
+				t.Errorf("got entry %+v, want action %s at version %d by user 1", entry, step.wantAction, before[0].Version+1)
+			}
+			fields := make([]string, 0, len(entry.Changes))
+			for field := range entry.Changes {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			if !reflect.DeepEqual(fields, step.wantFields) {
+				t.Errorf("got changes to %v, want changes to %v", fields, step.wantFields)
+			}
+		})
+	}
+	entries, _, err := c.History(as(1), project.ID, model.Filters{Page: 1, PageSize: 20, Sort: "version"})
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	created := entries[0]
+	if created.Action != model.AuditCreate || created.Version != 1 {
+		t.Fatalf("got first entry %+v, want the creation at version 1", created)
+	}
+	if change := created.Changes["name"]; change.Before != "" || change.After != "Apollo" {
+		t.Errorf("got name change %+v on creation, want from empty to Apollo", change)
+	}
+	if change := entries[1].Changes["name"]; change.Before != "Apollo" || change.After != "Apollo 11" {
+		t.Errorf("got name change %+v on update, want from Apollo to Apollo 11", change)
+	}
+}
+
+func TestHistoryOfTrashedProject(t *testing.T) {
+	c := newTestController(t)
+	project := mustCreate(t, c, "Apollo")
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "version"}
+	entries, _, err := c.History(as(1), project.ID, model.Filters{Page: 2, PageSize: 20, Sort: "version"})
+	if err != nil || len(entries) != 0 {
+		t.Errorf("History past the last page = %v, %v; want no entries", entries, err)
+	}
+	// The history of a trashed project stays readable.
+	if err := c.Delete(as(1), project.ID, 0, 1); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if entries, _, err := c.History(as(1), project.ID, filters); err != nil || len(entries) != 2 {
+		t.Errorf("History of a trashed project = %d entries, %v; want 2", len(entries), err)
+	}
+}
+
+// mustUpdate updates a project as user 1 and fails the test on error.
+func mustUpdate(t *testing.T, c *Controller, id int64, name, description *string, targetEndDate *time.Time) *model.Project {
+	t.Helper()
+	project, err := c.Update(as(1), id, 0, name, description, nil, targetEndDate, 1)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	return project
+}
+
+func TestGetAtVersion(t *testing.T) {
+	c := newTestController(t)
+	project := mustCreate(t, c, "Apollo")
+	name := "Apollo 11"
+	mustUpdate(t, c, project.ID, &name, nil, nil)
+	tests := []struct {
+		version  int64
+		wantName string
+		wantErr  error
+	}{
+		{1, "Apollo", nil},
+		{2, "Apollo 11", nil},
+		{3, "", controller.ErrNotFound},
+	}
+	for _, tt := range tests {
+		got, err := c.GetAtVersion(as(1), project.ID, tt.version)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("GetAtVersion(%d): got error %v, want %v", tt.version, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (got.Name != tt.wantName || got.Version != tt.version) {
+			t.Errorf("GetAtVersion(%d) = %+v, want %s at version %d", tt.version, got, tt.wantName, tt.version)
+		}
+	}
+	if got, err := c.GetAt(as(1), project.ID, time.Now().Add(time.Second)); err != nil || got.Name != "Apollo 11" {
+		t.Errorf("GetAt(now) = %+v, %v; want the current project", got, err)
+	}
+	if _, err := c.GetAt(as(1), project.ID, project.CreatedOn.Add(-time.Hour)); !errors.Is(err, controller.ErrNotFound) {
+		t.Errorf("GetAt before creation: got error %v, want ErrNotFound", err)
+	}
+}
+
+func TestRevert(t *testing.T) {
+	tests := []struct {
+		name     string
+		prepare  func(c *Controller, id int64) error // run before reverting.
+		version  int64
+		expected int64 // the expected version sent with the revert.
+		want     string
+		wantErr  error
+	}{
+		{name: "to the first version", version: 1, want: "Apollo"},
+		{name: "to the previous version", version: 2, want: "Apollo 11"},
+		{name: "to the current version", version: 3, want: "Apollo 11"},
+		{name: "at the expected version", version: 1, expected: 3, want: "Apollo"},
+		{name: "at a stale version", version: 1, expected: 2, wantErr: controller.ErrVersionMismatch},
+		{name: "to a missing version", version: 9, wantErr: controller.ErrNotFound},
+		{name: "an archived project", version: 1, wantErr: controller.ErrProjectArchived, prepare: func(c *Controller, id int64) error {
+			_, err := c.Archive(as(1), id, 1)
+			return err
+		}},
+		{name: "keeping the status", version: 1, want: "Apollo", prepare: func(c *Controller, id int64) error {
+			_, err := c.Transition(as(1), id, model.StatusActive, nil, 1)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(t)
+			project := mustCreate(t, c, "Apollo")
+			name, description, end := "Apollo 11", "Moon landing", testStart.AddDate(0, 2, 0)
+			mustUpdate(t, c, project.ID, &name, &description, nil)
+			current := mustUpdate(t, c, project.ID, nil, nil, &end)
+			if tt.prepare != nil {
+				if err := tt.prepare(c, project.ID); err != nil {
+					t.Fatalf("prepare: %v", err)
+				}
+				reloaded, err := c.Get(as(1), project.ID)
+				if err != nil {
+					t.Fatalf("Get: %v", err)
+				}
+				current = reloaded
+			}
+			got, err := c.Revert(as(1), project.ID, tt.version, tt.expected, 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			target, err := c.GetAtVersion(as(1), project.ID, tt.version)
+			if err != nil {
+				t.Fatalf("GetAtVersion: %v", err)
+			}
+			if got.Name != tt.want || got.Description != target.Description || !got.TargetEndDate.Equal(target.TargetEndDate) {
+				t.Errorf("got project %+v, want the details of version %d", got, tt.version)
+			}
+			if got.Status != current.Status {
+				t.Errorf("got status %s, want the status to stay %s", got.Status, current.Status)
+			}
+			entries, _, err := c.History(as(1), project.ID, model.Filters{Page: 1, PageSize: 20, Sort: "-version"})
+			if err != nil {
+				t.Fatalf("History: %v", err)
+			}
+			// Reverting to the details the project already has changes nothing.
+			if len(model.DiffProjects(current, target)) == 0 || tt.version == current.Version {
+				if got.Version != current.Version || entries[0].Version != current.Version {
+					t.Errorf("got version %d and last entry %+v, want the project left at version %d", got.Version, entries[0], current.Version)
+				}
+				return
+			}
+			if got.Version != current.Version+1 || entries[0].Action != model.AuditRevert || entries[0].Version != got.Version {
+				t.Errorf("got version %d and last entry %+v, want a revert recorded at version %d", got.Version, entries[0], current.Version+1)
+			}
+		})
+	}
+}
+
+func TestSnapshotUnavailable(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.New()
+	c := New(repo, authz.NewRBAC(repo), time.Hour)
+	// The project was created before snapshots were kept, so its first audit entry has none.
+	project := &model.Project{Name: "Apollo", Key: "APL", Status: model.StatusPlanned, StartDate: testStart, TargetEndDate: testStart.AddDate(0, 1, 0), CreatedBy: 1, ModifiedBy: 1}
+	if err := repo.Create(ctx, project); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := repo.AddMember(ctx, &model.Member{ProjectID: project.ID, UserID: 1, Role: model.RoleOwner, AddedBy: 1}); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	legacy := &model.AuditEntry{ProjectID: project.ID, Version: 1, Action: model.AuditCreate, Actor: 1, Changes: model.DiffProjects(nil, project)}
+	if err := repo.CreateAuditEntry(ctx, legacy); err != nil {
+		t.Fatalf("CreateAuditEntry: %v", err)
+	}
+	name := "Apollo 11"
+	mustUpdate(t, c, project.ID, &name, nil, nil)
+	if _, err := c.GetAtVersion(as(1), project.ID, 1); !errors.Is(err, controller.ErrSnapshotUnavailable) {
+		t.Errorf("GetAtVersion: got error %v, want ErrSnapshotUnavailable", err)
+	}
+	if _, err := c.Revert(as(1), project.ID, 1, 0, 1); !errors.Is(err, controller.ErrSnapshotUnavailable) {
+		t.Errorf("Revert: got error %v, want ErrSnapshotUnavailable", err)
+	}
+	if got, err := c.GetAtVersion(as(1), project.ID, 2); err != nil || got.Name != name {
+		t.Errorf("GetAtVersion(2) = %+v, %v; want the snapshot of the update", got, err)
+	}
+}
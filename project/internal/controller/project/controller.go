@@ -2,38 +2,139 @@ package project
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/emzola/venato/project/internal/auth"
+	"github.com/emzola/venato/project/internal/authz"
 	"github.com/emzola/venato/project/internal/controller"
 	"github.com/emzola/venato/project/internal/repository"
 	"github.com/emzola/venato/project/pkg/model"
 	"github.com/emzola/venato/project/pkg/validator"
 )
 
+// sortSafelist holds the supported sort values for project listings.
+var sortSafelist = []string{"id", "name", "created_on", "modified_on", "relevance", "-id", "-name", "-created_on", "-modified_on", "-relevance"}
+
 type projectRepository interface {
+	RunInTx(ctx context.Context, fn func(ctx context.Context) error) error
 	Create(ctx context.Context, project *model.Project) error
 	Get(ctx context.Context, id int64) (*model.Project, error)
-	// GetAll(ctx context.Context) ([]*model.Project, model.Metadata, error)
+	GetTrashed(ctx context.Context, id int64) (*model.Project, error)
+	GetByKey(ctx context.Context, key string) (*model.Project, error)
+	KeyExists(ctx context.Context, key string) (bool, error)
+	GetAll(ctx context.Context, query model.ProjectQuery, filters model.Filters) ([]*model.Project, model.Metadata, error)
 	Update(ctx context.Context, project *model.Project) error
-	Delete(ctx context.Context, id int64) error
+	Delete(ctx context.Context, id int64, deletedBy int64) error
+	Restore(ctx context.Context, id int64, restoredBy int64) (*model.Project, error)
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	AddMember(ctx context.Context, member *model.Member) error
+	GetMember(ctx context.Context, projectID, userID int64) (*model.Member, error)
+	GetMembers(ctx context.Context, projectID int64) ([]*model.Member, error)
+	UpdateMember(ctx context.Context, member *model.Member) error
+	RemoveMember(ctx context.Context, projectID, userID int64) error
+	CountOwners(ctx context.Context, projectID int64) (int, error)
+	CreateIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) error
+	GetIdempotencyKey(ctx context.Context, userID int64, key string) (*model.IdempotencyKey, error)
+	PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error)
+	CreateAuditEntry(ctx context.Context, entry *model.AuditEntry) error
+	GetHistory(ctx context.Context, projectID int64, filters model.Filters) ([]*model.AuditEntry, model.Metadata, error)
+	GetAuditEntry(ctx context.Context, projectID, version int64) (*model.AuditEntry, error)
+	GetAuditEntryAt(ctx context.Context, projectID int64, at time.Time) (*model.AuditEntry, error)
 }
 
 // Controller defines a new project service controller.
 type Controller struct {
-	repo projectRepository
+	repo           projectRepository
+	policy         authz.Policy
+	idempotencyTTL time.Duration
+}
+
+// New creates a project service controller which authorizes the caller carried by
+// each request context against the policy, and replays the response to a request
+// retried with the same idempotency key for idempotencyTTL.
+func New(repo projectRepository, policy authz.Policy, idempotencyTTL time.Duration) *Controller {
+	return &Controller{repo, policy, idempotencyTTL}
+}
+
+// authorize returns ErrPermissionDenied unless the caller in the context may perform the action on the project.
+func (c *Controller) authorize(ctx context.Context, action authz.Action, projectID int64) error {
+	principal, _ := auth.FromContext(ctx)
+	err := c.policy.Authorize(ctx, principal, action, projectID)
+	if err != nil {
+		switch {
+		case errors.Is(err, authz.ErrPermissionDenied) && principal != nil && projectID != 0:
+			return c.deniedError(ctx, principal, action, projectID)
+		case errors.Is(err, authz.ErrPermissionDenied):
+			return controller.ErrPermissionDenied
+		default:
+			return translateError(err)
+		}
+	}
+	return nil
 }
 
-// New creates a project service controller.
-func New(repo projectRepository) *Controller {
-	return &Controller{repo}
+// deniedError returns the error for a principal denied an action on a project. A principal
+// who may not read the project gets ErrNotFound, whether or not the project exists, so
+// that callers cannot learn which projects exist by probing for them.
+func (c *Controller) deniedError(ctx context.Context, principal *auth.Principal, action authz.Action, projectID int64) error {
+	if action == authz.ActionRead {
+		return controller.ErrNotFound
+	}
+	err := c.policy.Authorize(ctx, principal, authz.ActionRead, projectID)
+	if err != nil {
+		switch {
+		case errors.Is(err, authz.ErrPermissionDenied):
+			return controller.ErrNotFound
+		default:
+			return translateError(err)
+		}
+	}
+	return controller.ErrPermissionDenied
 }
 
-// Create creates a new project.
-func (c *Controller) Create(ctx context.Context, name, description string, startDate, targetEndDate time.Time, createdBy, modifiedBy int64) (*model.Project, error) {
+// Create creates a new project owned by its creator. When no key is supplied, a
+// unique key is suggested from the project name. Keys cannot be changed after creation.
+// When an idempotency key is supplied, a retry of the request returns the project
+// created by the first attempt instead of creating another one.
+func (c *Controller) Create(ctx context.Context, name, key, description string, startDate, targetEndDate time.Time, createdBy, modifiedBy int64, idempotencyKey string) (*model.Project, error) {
+	if err := c.authorize(ctx, authz.ActionCreate, 0); err != nil {
+		return nil, err
+	}
+	var record *model.IdempotencyKey
+	if idempotencyKey != "" {
+		v := validator.New()
+		if model.ValidateIdempotencyKey(v, idempotencyKey); !v.Valid() {
+			return nil, controller.FailedValidation(v.Errors)
+		}
+		record = &model.IdempotencyKey{
+			UserID:      createdBy,
+			Key:         idempotencyKey,
+			RequestHash: createRequestHash(name, key, description, startDate, targetEndDate),
+		}
+		project, err := c.replay(ctx, record)
+		if !errors.Is(err, controller.ErrNotFound) {
+			return project, err
+		}
+	}
+	key = strings.ToUpper(strings.TrimSpace(key))
+	if key == "" {
+		var err error
+		key, err = c.suggestKey(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+	}
 	project := &model.Project{
 		Name:          name,
+		Key:           key,
 		Description:   description,
+		Status:        model.StatusPlanned,
 		StartDate:     startDate,
 		TargetEndDate: targetEndDate,
 		CreatedBy:     createdBy,
@@ -41,46 +142,204 @@ func (c *Controller) Create(ctx context.Context, name, description string, start
 	}
 	v := validator.New()
 	if model.ValidateProject(v, project); !v.Valid() {
-		controller.ErrFailedValidation = controller.FailedValidation(v.Errors)
-		return nil, controller.ErrFailedValidation
+		return nil, controller.FailedValidation(v.Errors)
+	}
+	// The project, its owner membership and its first audit entry are stored together or not at all.
+	err := c.repo.RunInTx(ctx, func(ctx context.Context) error {
+		if err := c.repo.Create(ctx, project); err != nil {
+			return err
+		}
+		owner := &model.Member{ProjectID: project.ID, UserID: createdBy, Role: model.RoleOwner, AddedBy: createdBy}
+		if err := c.repo.AddMember(ctx, owner); err != nil {
+			return err
+		}
+		if err := c.audit(ctx, model.AuditCreate, nil, project, createdBy); err != nil {
+			return err
+		}
+		if record == nil {
+			return nil
+		}
+		response, err := json.Marshal(project)
+		if err != nil {
+			return err
+		}
+		record.Response = response
+		record.ExpiresOn = time.Now().Add(c.idempotencyTTL)
+		return c.repo.CreateIdempotencyKey(ctx, record)
+	})
+	if err != nil && record != nil && (errors.Is(err, repository.ErrDuplicateIdempotencyKey) || errors.Is(err, repository.ErrDuplicateKey)) {
+		// A concurrent request with the same idempotency key may have created the project first.
+		project, replayErr := c.replay(ctx, record)
+		if !errors.Is(replayErr, controller.ErrNotFound) {
+			return project, replayErr
+		}
 	}
-	err := c.repo.Create(ctx, project)
 	if err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, repository.ErrDuplicateKey):
+			v.AddError("key", "a project with this key already exists")
+			return nil, controller.FailedValidation(v.Errors)
+		default:
+			return nil, translateError(err)
+		}
 	}
 	return project, nil
 }
 
+// replay returns the project created by an earlier request made with the same idempotency
+// key as record. It returns ErrNotFound if there was no such request, and
+// ErrIdempotencyKeyReused if the earlier request differed from this one.
+func (c *Controller) replay(ctx context.Context, record *model.IdempotencyKey) (*model.Project, error) {
+	stored, err := c.repo.GetIdempotencyKey(ctx, record.UserID, record.Key)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, controller.ErrNotFound
+		default:
+			return nil, translateError(err)
+		}
+	}
+	if stored.RequestHash != record.RequestHash {
+		return nil, controller.ErrIdempotencyKeyReused
+	}
+	var project model.Project
+	if err := json.Unmarshal(stored.Response, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// createRequestHash returns a digest of the fields of a request to create a project,
+// which tells a retry of the request apart from a different request.
+func createRequestHash(name, key, description string, startDate, targetEndDate time.Time) string {
+	request, _ := json.Marshal([]interface{}{name, key, description, startDate.UTC(), targetEndDate.UTC()})
+	sum := sha256.Sum256(request)
+	return hex.EncodeToString(sum[:])
+}
+
+// suggestKey returns a key derived from a project name which is not yet
+// taken, appending a number to the derived key until it is unique.
+func (c *Controller) suggestKey(ctx context.Context, name string) (string, error) {
+	base := model.SuggestKey(name)
+	key := base
+	for i := 2; ; i++ {
+		exists, err := c.repo.KeyExists(ctx, key)
+		if err != nil {
+			return "", translateError(err)
+		}
+		if !exists {
+			return key, nil
+		}
+		suffix := strconv.Itoa(i)
+		if len(base)+len(suffix) > 10 {
+			base = base[:10-len(suffix)]
+		}
+		key = base + suffix
+	}
+}
+
 // Get retrieves a project by id.
 func (c *Controller) Get(ctx context.Context, id int64) (*model.Project, error) {
+	if err := c.authorize(ctx, authz.ActionRead, id); err != nil {
+		return nil, err
+	}
 	project, err := c.repo.Get(ctx, id)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrNotFound):
 			return nil, controller.ErrNotFound
 		default:
-			return nil, err
+			return nil, translateError(err)
 		}
 	}
 	return project, nil
 }
 
-// GetAll retrieves a paginated list of all projects.
-// func (c *Controller) GetAll(ctx context.Context) ([]*model.Project, model.Metadata, error) {
+// GetByKey retrieves a project by its key.
+func (c *Controller) GetByKey(ctx context.Context, key string) (*model.Project, error) {
+	project, err := c.repo.GetByKey(ctx, strings.ToUpper(key))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, controller.ErrNotFound
+		default:
+			return nil, translateError(err)
+		}
+	}
+	// The key can only be resolved to a project before authorizing, which is
+	// safe because authorize reports unreadable projects as not found.
+	if err := c.authorize(ctx, authz.ActionRead, project.ID); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
 
-// }
+// GetAll retrieves a paginated list of all projects matching the given query.
+// Callers who may not list every project only see the projects they are a member of.
+func (c *Controller) GetAll(ctx context.Context, query model.ProjectQuery, filters model.Filters) ([]*model.Project, model.Metadata, error) {
+	err := c.authorize(ctx, authz.ActionListAll, 0)
+	if err != nil {
+		switch {
+		case errors.Is(err, controller.ErrPermissionDenied):
+			principal, ok := auth.FromContext(ctx)
+			if !ok {
+				return nil, model.Metadata{}, err
+			}
+			query.MemberID = principal.UserID
+		default:
+			return nil, model.Metadata{}, err
+		}
+	}
+	filters.SortSafelist = sortSafelist
+	v := validator.New()
+	model.ValidateFilters(v, filters)
+	if strings.TrimPrefix(filters.Sort, "-") == "relevance" {
+		v.Check(query.Search != "", "sort", "relevance sort requires a search term")
+		v.Check(!filters.CursorMode, "sort", "relevance sort does not support cursor pagination")
+	}
+	if !v.Valid() {
+		return nil, model.Metadata{}, controller.FailedValidation(v.Errors)
+	}
+	projects, metadata, err := c.repo.GetAll(ctx, query, filters)
+	if err != nil {
+		return nil, model.Metadata{}, translateError(err)
+	}
+	return projects, metadata, nil
+}
 
-// Update partially updates a project record.
-func (c *Controller) Update(ctx context.Context, id int64, name, description *string, startDate, targetEndDate, actualEndDate *time.Time, modifiedBy int64) (*model.Project, error) {
+// Search retrieves a paginated list of projects whose name or description match the search term.
+func (c *Controller) Search(ctx context.Context, search string, filters model.Filters) ([]*model.Project, model.Metadata, error) {
+	v := validator.New()
+	if v.Check(strings.TrimSpace(search) != "", "query", "must be provided"); !v.Valid() {
+		return nil, model.Metadata{}, controller.FailedValidation(v.Errors)
+	}
+	return c.GetAll(ctx, model.ProjectQuery{Search: search}, filters)
+}
+
+// Update partially updates a project record. The status and actual end date
+// of a project are changed through Transition instead. If expectedVersion is not
+// zero, ErrVersionMismatch is returned unless the project is still at that version.
+// An update which changes nothing returns the project as it is, without writing it.
+func (c *Controller) Update(ctx context.Context, id, expectedVersion int64, name, description *string, startDate, targetEndDate *time.Time, modifiedBy int64) (*model.Project, error) {
+	if err := c.authorize(ctx, authz.ActionUpdate, id); err != nil {
+		return nil, err
+	}
 	project, err := c.repo.Get(ctx, id)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrNotFound):
 			return nil, controller.ErrNotFound
 		default:
-			return nil, err
+			return nil, translateError(err)
 		}
 	}
+	if expectedVersion != 0 && project.Version != expectedVersion {
+		return nil, controller.ErrVersionMismatch
+	}
+	if project.ArchivedOn != nil {
+		return nil, controller.ErrProjectArchived
+	}
+	before := *project
 	// Partially update the project with new data based on whether new data is supplied by the client.
 	if name != nil {
 		project.Name = *name
@@ -94,37 +353,177 @@ func (c *Controller) Update(ctx context.Context, id int64, name, description *st
 	if targetEndDate != nil {
 		project.TargetEndDate = *targetEndDate
 	}
-	if actualEndDate != nil {
-		project.ActualEndDate = *actualEndDate
+	if len(model.DiffProjects(&before, project)) == 0 {
+		return &before, nil
 	}
 	project.ModifiedBy = modifiedBy
 	v := validator.New()
 	if model.ValidateProject(v, project); !v.Valid() {
-		controller.ErrFailedValidation = controller.FailedValidation(v.Errors)
-		return nil, controller.ErrFailedValidation
+		return nil, controller.FailedValidation(v.Errors)
 	}
-	err = c.repo.Update(ctx, project)
+	err = c.update(ctx, model.AuditUpdate, &before, project)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrEditConflict):
 			return nil, controller.ErrEditConflict
 		default:
-			return nil, err
+			return nil, translateError(err)
 		}
 	}
 	return project, nil
 }
 
-// Delete removes a project by its id.
-func (c *Controller) Delete(ctx context.Context, id int64) error {
-	err := c.repo.Delete(ctx, id)
+// Archive marks a project as archived, making it read-only.
+func (c *Controller) Archive(ctx context.Context, id int64, archivedBy int64) (*model.Project, error) {
+	if err := c.authorize(ctx, authz.ActionArchive, id); err != nil {
+		return nil, err
+	}
+	project, err := c.repo.Get(ctx, id)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrNotFound):
-			return controller.ErrNotFound
+			return nil, controller.ErrNotFound
+		default:
+			return nil, translateError(err)
+		}
+	}
+	if project.ArchivedOn != nil {
+		return nil, controller.ErrProjectArchived
+	}
+	before := *project
+	archivedOn := time.Now().UTC().Truncate(time.Second)
+	project.ArchivedOn = &archivedOn
+	project.ArchivedBy = archivedBy
+	project.ModifiedBy = archivedBy
+	err = c.update(ctx, model.AuditArchive, &before, project)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, controller.ErrEditConflict
+		default:
+			return nil, translateError(err)
+		}
+	}
+	return project, nil
+}
+
+// Unarchive returns an archived project to the active projects.
+func (c *Controller) Unarchive(ctx context.Context, id int64, unarchivedBy int64) (*model.Project, error) {
+	if err := c.authorize(ctx, authz.ActionArchive, id); err != nil {
+		return nil, err
+	}
+	project, err := c.repo.Get(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, controller.ErrNotFound
+		default:
+			return nil, translateError(err)
+		}
+	}
+	if project.ArchivedOn == nil {
+		return nil, controller.ErrProjectNotArchived
+	}
+	before := *project
+	project.ArchivedOn = nil
+	project.ArchivedBy = 0
+	project.ModifiedBy = unarchivedBy
+	err = c.update(ctx, model.AuditUnarchive, &before, project)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, controller.ErrEditConflict
 		default:
+			return nil, translateError(err)
+		}
+	}
+	return project, nil
+}
+
+// Delete moves a project to the trash by its id. If expectedVersion is not zero,
+// ErrVersionMismatch is returned unless the project is still at that version.
+func (c *Controller) Delete(ctx context.Context, id, expectedVersion int64, deletedBy int64) error {
+	if err := c.authorize(ctx, authz.ActionDelete, id); err != nil {
+		return err
+	}
+	err := c.repo.RunInTx(ctx, func(ctx context.Context) error {
+		before, err := c.repo.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if expectedVersion != 0 && before.Version != expectedVersion {
+			return controller.ErrVersionMismatch
+		}
+		if err := c.repo.Delete(ctx, id, deletedBy); err != nil {
+			return err
+		}
+		after, err := c.repo.GetTrashed(ctx, id)
+		if err != nil {
 			return err
 		}
+		// The project changed between reading and deleting it, so the change cannot be audited.
+		if after.Version != before.Version+1 {
+			return repository.ErrEditConflict
+		}
+		return c.audit(ctx, model.AuditDelete, before, after, deletedBy)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return controller.ErrNotFound
+		case errors.Is(err, repository.ErrEditConflict):
+			return controller.ErrEditConflict
+		case errors.Is(err, controller.ErrVersionMismatch):
+			return controller.ErrVersionMismatch
+		default:
+			return translateError(err)
+		}
 	}
 	return nil
 }
+
+// Restore moves a project out of the trash by its id.
+func (c *Controller) Restore(ctx context.Context, id int64, restoredBy int64) (*model.Project, error) {
+	if err := c.authorize(ctx, authz.ActionRestore, id); err != nil {
+		return nil, err
+	}
+	var project *model.Project
+	err := c.repo.RunInTx(ctx, func(ctx context.Context) error {
+		before, err := c.repo.GetTrashed(ctx, id)
+		if err != nil {
+			return err
+		}
+		project, err = c.repo.Restore(ctx, id, restoredBy)
+		if err != nil {
+			return err
+		}
+		// The project changed between reading and restoring it, so the change cannot be audited.
+		if project.Version != before.Version+1 {
+			return repository.ErrEditConflict
+		}
+		return c.audit(ctx, model.AuditRestore, before, project, restoredBy)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, controller.ErrNotFound
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, controller.ErrEditConflict
+		default:
+			return nil, translateError(err)
+		}
+	}
+	return project, nil
+}
+
+// PurgeTrash permanently removes projects that have been in the trash for
+// longer than the retention period, and returns the number of projects removed.
+func (c *Controller) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	return c.repo.Purge(ctx, time.Now().Add(-retention))
+}
+
+// PurgeIdempotencyKeys permanently removes the idempotency keys which have
+// expired, and returns the number of keys removed.
+func (c *Controller) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	return c.repo.PurgeIdempotencyKeys(ctx, time.Now())
+}
@@ -0,0 +1,419 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emzola/venato/project/internal/auth"
+	"github.com/emzola/venato/project/internal/authz"
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/emzola/venato/project/internal/repository/memory"
+	"github.com/emzola/venato/project/pkg/model"
+)
+
+// testStart is the start date of the projects created by the tests.
+var testStart = time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestController returns a controller backed by an empty in-memory repository.
+func newTestController(t *testing.T) *Controller {
+	t.Helper()
+	repo := memory.New()
+	return New(repo, authz.NewRBAC(repo), time.Hour)
+}
+
+// as returns a context carrying the user with the given id as the caller.
+func as(userID int64) context.Context {
+	return auth.NewContext(context.Background(), &auth.Principal{UserID: userID})
+}
+
+// mustCreate creates a project owned by user 1 and fails the test on error.
+func mustCreate(t *testing.T, c *Controller, name string) *model.Project {
+	t.Helper()
+	project, err := c.Create(as(1), name, "", "", testStart, testStart.AddDate(0, 1, 0), 1, 1, "")
+	if err != nil {
+		t.Fatalf("Create(%q): %v", name, err)
+	}
+	return project
+}
+
+func TestArchive(t *testing.T) {
+	name := "Apollo 11"
+	tests := []struct {
+		name string
+		fn   func(c *Controller, id int64) error
+	}{
+		{"update", func(c *Controller, id int64) error {
+			_, err := c.Update(as(1), id, 0, &name, nil, nil, nil, 1)
+			return err
+		}},
+		{"transition", func(c *Controller, id int64) error {
+			_, err := c.Transition(as(1), id, model.StatusActive, nil, 1)
+			return err
+		}},
+		{"archive again", func(c *Controller, id int64) error {
+			_, err := c.Archive(as(1), id, 1)
+			return err
+		}},
+		{"add member", func(c *Controller, id int64) error {
+			_, err := c.AddMember(as(1), id, 3, model.RoleViewer, 1)
+			return err
+		}},
+		{"update member", func(c *Controller, id int64) error {
+			_, err := c.UpdateMember(as(1), id, 2, model.RoleAdmin)
+			return err
+		}},
+		{"remove member", func(c *Controller, id int64) error {
+			return c.RemoveMember(as(1), id, 2)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(t)
+			project := mustCreate(t, c, "Apollo")
+			if _, err := c.AddMember(as(1), project.ID, 2, model.RoleMember, 1); err != nil {
+				t.Fatalf("AddMember: %v", err)
+			}
+			archived, err := c.Archive(as(1), project.ID, 1)
+			if err != nil {
+				t.Fatalf("Archive: %v", err)
+			}
+			if archived.ArchivedOn == nil || archived.ArchivedBy != 1 {
+				t.Fatalf("got archived project %+v, want archived by user 1", archived)
+			}
+			if err := tt.fn(c, project.ID); !errors.Is(err, controller.ErrProjectArchived) {
+				t.Errorf("got error %v, want ErrProjectArchived", err)
+			}
+			got, err := c.Get(as(1), project.ID)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if got.Version != archived.Version || got.Name != "Apollo" || got.Status != model.StatusPlanned {
+				t.Errorf("got project %+v after the rejected change, want it unchanged", got)
+			}
+			members, err := c.GetMembers(as(1), project.ID)
+			if err != nil || len(members) != 2 || members[1].Role != model.RoleMember {
+				t.Errorf("GetMembers = %+v, %v; want the members unchanged", members, err)
+			}
+		})
+	}
+}
+
+func TestUnarchive(t *testing.T) {
+	c := newTestController(t)
+	project := mustCreate(t, c, "Apollo")
+	if _, err := c.Unarchive(as(1), project.ID, 1); !errors.Is(err, controller.ErrProjectNotArchived) {
+		t.Fatalf("Unarchive of an active project: got error %v, want ErrProjectNotArchived", err)
+	}
+	if _, err := c.Archive(as(1), project.ID, 1); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	unarchived, err := c.Unarchive(as(1), project.ID, 1)
+	if err != nil {
+		t.Fatalf("Unarchive: %v", err)
+	}
+	if unarchived.ArchivedOn != nil || unarchived.ArchivedBy != 0 {
+		t.Errorf("got unarchived project %+v, want no archive details", unarchived)
+	}
+	name := "Apollo 11"
+	if _, err := c.Update(as(1), project.ID, 0, &name, nil, nil, nil, 1); err != nil {
+		t.Errorf("Update after Unarchive: %v", err)
+	}
+}
+
+func TestCreateKey(t *testing.T) {
+	c := newTestController(t)
+	trashed, err := c.Create(as(1), "Trashed", "TRS", "", testStart, testStart.AddDate(0, 1, 0), 1, 1, "")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := c.Delete(as(1), trashed.ID, 0, 1); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	// The cases share the controller, so each key is suggested in view of the ones before it.
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{name: "Venato", want: "VEN"},
+		{name: "Venator", want: "VEN2"},
+		{name: "Venatic", want: "VEN3"},
+		{name: "Venato Issue Tracker", want: "VIT"},
+		{name: "A B C D E F G H I J K", want: "ABCDEFGHIJ"},
+		{name: "A B C D E F G H I J", want: "ABCDEFGHI2"},
+		{name: "42", want: "PRJ"},
+		{name: "Apollo", key: " apl ", want: "APL"},
+		{name: "Apollo 2", key: "APL", wantErr: true},
+		{name: "Other", key: "TRS", wantErr: true},
+		{name: "Digits", key: "1AB", wantErr: true},
+		{name: "Long", key: "ABCDEFGHIJK", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project, err := c.Create(as(1), tt.name, tt.key, "", testStart, testStart.AddDate(0, 1, 0), 1, 1, "")
+			if tt.wantErr {
+				var validationErr *controller.ValidationError
+				if !errors.As(err, &validationErr) || validationErr.Errors["key"] == "" {
+					t.Fatalf("got error %v, want a validation error for key", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if project.Key != tt.want {
+				t.Errorf("got key %q, want %q", project.Key, tt.want)
+			}
+			got, err := c.GetByKey(as(1), strings.ToLower(tt.want))
+			if err != nil || got.ID != project.ID {
+				t.Errorf("GetByKey(%q) = %+v, %v; want project %d", strings.ToLower(tt.want), got, err, project.ID)
+			}
+		})
+	}
+}
+
+func TestMissingProjects(t *testing.T) {
+	c := newTestController(t)
+	project := mustCreate(t, c, "Apollo")
+	if _, err := c.AddMember(as(1), project.ID, 2, model.RoleViewer, 1); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	name := "Apollo 11"
+	operations := map[string]func(ctx context.Context, id int64) error{
+		"get": func(ctx context.Context, id int64) error {
+			_, err := c.Get(ctx, id)
+			return err
+		},
+		"get by key": func(ctx context.Context, id int64) error {
+			key := project.Key
+			if id != project.ID {
+				key = "NOPE"
+			}
+			_, err := c.GetByKey(ctx, key)
+			return err
+		},
+		"update": func(ctx context.Context, id int64) error {
+			_, err := c.Update(ctx, id, 0, &name, nil, nil, nil, 3)
+			return err
+		},
+		"delete": func(ctx context.Context, id int64) error {
+			return c.Delete(ctx, id, 0, 3)
+		},
+		"members": func(ctx context.Context, id int64) error {
+			_, err := c.GetMembers(ctx, id)
+			return err
+		},
+		"history": func(ctx context.Context, id int64) error {
+			_, _, err := c.History(ctx, id, model.Filters{Page: 1, PageSize: 20, Sort: "version"})
+			return err
+		},
+	}
+	// Callers who may not read a project cannot tell it apart from a missing one,
+	// and only callers who may read it learn that they may not change it.
+	tests := []struct {
+		name    string
+		userID  int64
+		id      int64
+		wantErr map[string]error
+	}{
+		{"missing project", 3, 99, nil},
+		{"project of others", 3, project.ID, nil},
+		{"missing project of a member", 2, 99, nil},
+		{"project of a viewer", 2, project.ID, map[string]error{
+			"get":        nil,
+			"get by key": nil,
+			"update":     controller.ErrPermissionDenied,
+			"delete":     controller.ErrPermissionDenied,
+			"members":    nil,
+			"history":    nil,
+		}},
+	}
+	for _, tt := range tests {
+		for op, fn := range operations {
+			t.Run(tt.name+"/"+op, func(t *testing.T) {
+				want := controller.ErrNotFound
+				if tt.wantErr != nil {
+					want = tt.wantErr[op]
+				}
+				if err := fn(as(tt.userID), tt.id); !errors.Is(err, want) {
+					t.Errorf("got error %v, want %v", err, want)
+				}
+			})
+		}
+	}
+}
+
+// timingOutRepository is a repository whose listings exceed the statement timeout.
+type timingOutRepository struct {
+	*memory.Repository
+}
+
+func (r timingOutRepository) GetAll(ctx context.Context, query model.ProjectQuery, filters model.Filters) ([]*model.Project, model.Metadata, error) {
+	return nil, model.Metadata{}, repository.ErrQueryCanceled
+}
+
+func TestListingTimeout(t *testing.T) {
+	repo := memory.New()
+	c := New(timingOutRepository{repo}, authz.NewRBAC(repo), time.Hour)
+	filters := model.Filters{Page: 1, PageSize: 20, Sort: "id"}
+	if _, _, err := c.GetAll(as(1), model.ProjectQuery{}, filters); !errors.Is(err, controller.ErrTimeout) {
+		t.Errorf("GetAll: got error %v, want ErrTimeout", err)
+	}
+	if _, _, err := c.Search(as(1), "apollo", filters); !errors.Is(err, controller.ErrTimeout) {
+		t.Errorf("Search: got error %v, want ErrTimeout", err)
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	long := strings.Repeat("a", 501)
+	tests := []struct {
+		name       string
+		fn         func(c *Controller, id int64) error
+		wantFields []string
+	}{
+		{"create without a name", func(c *Controller, id int64) error {
+			_, err := c.Create(as(1), "", "APL", "", testStart, testStart.AddDate(0, 1, 0), 1, 1, "")
+			return err
+		}, []string{"name"}},
+		{"create with an idempotency key which is too long", func(c *Controller, id int64) error {
+			_, err := c.Create(as(1), "Gemini", "", "", testStart, testStart.AddDate(0, 1, 0), 1, 1, strings.Repeat("k", 256))
+			return err
+		}, []string{"idempotency_key"}},
+		{"create with every project field invalid", func(c *Controller, id int64) error {
+			_, err := c.Create(as(1), long, "a", strings.Repeat("a", 1001), testStart, testStart, 1, 1, "")
+			return err
+		}, []string{"name", "key", "description", "target_end_date"}},
+		{"update with a target end date before the start date", func(c *Controller, id int64) error {
+			end := testStart.AddDate(0, 0, -1)
+			_, err := c.Update(as(1), id, 0, nil, nil, nil, &end, 1)
+			return err
+		}, []string{"target_end_date"}},
+		{"update with an empty name", func(c *Controller, id int64) error {
+			name := ""
+			_, err := c.Update(as(1), id, 0, &name, nil, nil, nil, 1)
+			return err
+		}, []string{"name"}},
+		{"transition to an unknown status", func(c *Controller, id int64) error {
+			_, err := c.Transition(as(1), id, "done", nil, 1)
+			return err
+		}, []string{"status"}},
+		{"add a member with an unknown role", func(c *Controller, id int64) error {
+			_, err := c.AddMember(as(1), id, 2, "boss", 1)
+			return err
+		}, []string{"role"}},
+		{"add a member without a user", func(c *Controller, id int64) error {
+			_, err := c.AddMember(as(1), id, 0, model.RoleViewer, 1)
+			return err
+		}, []string{"user_id"}},
+		{"search without a query", func(c *Controller, id int64) error {
+			_, _, err := c.Search(as(1), " ", model.Filters{Page: 1, PageSize: 20, Sort: "id"})
+			return err
+		}, []string{"query"}},
+		{"list with invalid filters", func(c *Controller, id int64) error {
+			_, _, err := c.GetAll(as(1), model.ProjectQuery{}, model.Filters{Page: 0, PageSize: 101, Sort: "size"})
+			return err
+		}, []string{"page", "page_size", "sort"}},
+		{"list by relevance without a search", func(c *Controller, id int64) error {
+			_, _, err := c.GetAll(as(1), model.ProjectQuery{}, model.Filters{Page: 1, PageSize: 20, Sort: "-relevance"})
+			return err
+		}, []string{"sort"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(t)
+			project := mustCreate(t, c, "Apollo")
+			var validationErr *controller.ValidationError
+			if err := tt.fn(c, project.ID); !errors.As(err, &validationErr) {
+				t.Fatalf("got error %v, want a ValidationError", err)
+			}
+			if len(validationErr.Errors) != len(tt.wantFields) {
+				t.Errorf("got errors %v, want errors for %v", validationErr.Errors, tt.wantFields)
+			}
+			for _, field := range tt.wantFields {
+				if validationErr.Errors[field] == "" {
+					t.Errorf("got errors %v, want an error for %s", validationErr.Errors, field)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateIdempotency(t *testing.T) {
+	end := testStart.AddDate(0, 1, 0)
+	create := func(c *Controller, userID int64, name, idempotencyKey string) (*model.Project, error) {
+		return c.Create(as(userID), name, "", "", testStart, end, userID, userID, idempotencyKey)
+	}
+	tests := []struct {
+		name        string
+		userID      int64
+		projectName string
+		key         string
+		wantReplay  bool // whether the first project is returned instead of a new one.
+		wantErr     error
+	}{
+		{"retry", 1, "Apollo", "create-apollo", true, nil},
+		{"retry with a different request", 1, "Gemini", "create-apollo", false, controller.ErrIdempotencyKeyReused},
+		{"same request with another key", 1, "Apollo", "create-apollo-again", false, nil},
+		{"same request without a key", 1, "Apollo", "", false, nil},
+		{"same key of another user", 2, "Apollo", "create-apollo", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(t)
+			first, err := create(c, 1, "Apollo", "create-apollo")
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			got, err := create(c, tt.userID, tt.projectName, tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if replayed := got.ID == first.ID; replayed != tt.wantReplay {
+				t.Fatalf("got project %d after creating project %d, want replayed %v", got.ID, first.ID, tt.wantReplay)
+			}
+			if tt.wantReplay && (got.Key != first.Key || got.Version != first.Version || !got.CreatedOn.Equal(first.CreatedOn)) {
+				t.Errorf("got replayed project %+v, want %+v", got, first)
+			}
+			projects, _, err := c.GetAll(as(1), model.ProjectQuery{}, model.Filters{Page: 1, PageSize: 20, Sort: "id"})
+			if err != nil {
+				t.Fatalf("GetAll: %v", err)
+			}
+			wantProjects := 2
+			switch {
+			case tt.wantReplay:
+				wantProjects = 1
+			case tt.userID != 1:
+				wantProjects = 1 // the other user's project is not visible to user 1.
+			}
+			if len(projects) != wantProjects {
+				t.Errorf("got %d projects, want %d", len(projects), wantProjects)
+			}
+		})
+	}
+}
+
+func TestCreateIdempotencyExpiry(t *testing.T) {
+	repo := memory.New()
+	c := New(repo, authz.NewRBAC(repo), -time.Second)
+	end := testStart.AddDate(0, 1, 0)
+	first, err := c.Create(as(1), "Apollo", "", "", testStart, end, 1, 1, "create-apollo")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	// Once a key expires, it may be used for another request.
+	second, err := c.Create(as(1), "Gemini", "", "", testStart, end, 1, 1, "create-apollo")
+	if err != nil {
+		t.Fatalf("Create with an expired key: %v", err)
+	}
+	if second.ID == first.ID {
+		t.Errorf("got project %d again, want a new project", second.ID)
+	}
+}
@@ -0,0 +1,43 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/emzola/venato/project/pkg/validator"
+)
+
+// checkConstraints maps the data integrity checks enforced by the repository to
+// the field they concern and the validation message reported for it.
+var checkConstraints = map[string][2]string{
+	"project_target_end_date_check": {"target_end_date", "must not be before start date"},
+	"project_actual_end_date_check": {"actual_end_date", "must not be before start date"},
+	"project_status_check":          {"status", "must be a valid status"},
+	"project_member_role_check":     {"role", "must be a valid role"},
+}
+
+// translateError converts the repository errors that any operation may run into
+// to controller errors. Other errors are returned unchanged.
+func translateError(err error) error {
+	var constraintErr *repository.ConstraintError
+	switch {
+	case errors.As(err, &constraintErr) && errors.Is(err, repository.ErrCheckViolation):
+		check, ok := checkConstraints[constraintErr.Constraint]
+		if !ok {
+			return fmt.Errorf("%w: %v", controller.ErrConflict, err)
+		}
+		v := validator.New()
+		v.AddError(check[0], check[1])
+		return controller.FailedValidation(v.Errors)
+	case errors.Is(err, repository.ErrUniqueViolation), errors.Is(err, repository.ErrForeignKeyViolation):
+		return fmt.Errorf("%w: %v", controller.ErrConflict, err)
+	case errors.Is(err, repository.ErrSerializationFailure):
+		return controller.ErrEditConflict
+	case errors.Is(err, repository.ErrQueryCanceled):
+		return fmt.Errorf("%w: %v", controller.ErrTimeout, err)
+	default:
+		return err
+	}
+}
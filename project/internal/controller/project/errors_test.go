@@ -0,0 +1,46 @@
+package project
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/internal/repository"
+)
+
+func TestTranslateError(t *testing.T) {
+	errOther := errors.New("other")
+	tests := []struct {
+		name      string
+		err       error
+		wantErr   error
+		wantField string
+	}{
+		{"target end date check", &repository.ConstraintError{Constraint: "project_target_end_date_check", Err: repository.ErrCheckViolation}, controller.ErrFailedValidation, "target_end_date"},
+		{"actual end date check", &repository.ConstraintError{Constraint: "project_actual_end_date_check", Err: repository.ErrCheckViolation}, controller.ErrFailedValidation, "actual_end_date"},
+		{"status check", &repository.ConstraintError{Constraint: "project_status_check", Err: repository.ErrCheckViolation}, controller.ErrFailedValidation, "status"},
+		{"member role check", &repository.ConstraintError{Constraint: "project_member_role_check", Err: repository.ErrCheckViolation}, controller.ErrFailedValidation, "role"},
+		{"unknown check", &repository.ConstraintError{Constraint: "project_name_check", Err: repository.ErrCheckViolation}, controller.ErrConflict, ""},
+		{"unique violation", &repository.ConstraintError{Constraint: "project_name_key", Err: repository.ErrUniqueViolation}, controller.ErrConflict, ""},
+		{"foreign key violation", repository.ErrForeignKeyViolation, controller.ErrConflict, ""},
+		{"serialization failure", repository.ErrSerializationFailure, controller.ErrEditConflict, ""},
+		{"query canceled", repository.ErrQueryCanceled, controller.ErrTimeout, ""},
+		{"other error", errOther, errOther, ""},
+		{"no error", nil, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := translateError(tt.err)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantField == "" {
+				return
+			}
+			var validationErr *controller.ValidationError
+			if !errors.As(err, &validationErr) || len(validationErr.Errors) != 1 || validationErr.Errors[tt.wantField] == "" {
+				t.Errorf("got error %v, want a validation error for %s only", err, tt.wantField)
+			}
+		})
+	}
+}
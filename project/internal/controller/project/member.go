@@ -0,0 +1,202 @@
+package project
+
+import (
+	"context"
+	"errors"
+
+	"github.com/emzola/venato/project/internal/authz"
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/emzola/venato/project/pkg/model"
+	"github.com/emzola/venato/project/pkg/validator"
+)
+
+// AddMember adds a user to a project with the given role.
+func (c *Controller) AddMember(ctx context.Context, projectID, userID int64, role model.Role, addedBy int64) (*model.Member, error) {
+	if err := c.authorizeMemberChange(ctx, projectID, role); err != nil {
+		return nil, err
+	}
+	member := &model.Member{
+		ProjectID: projectID,
+		UserID:    userID,
+		Role:      role,
+		AddedBy:   addedBy,
+	}
+	v := validator.New()
+	if model.ValidateMember(v, member); !v.Valid() {
+		return nil, controller.FailedValidation(v.Errors)
+	}
+	if err := c.checkModifiable(ctx, projectID); err != nil {
+		return nil, err
+	}
+	err := c.repo.AddMember(ctx, member)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, controller.ErrNotFound
+		case errors.Is(err, repository.ErrDuplicateMember):
+			return nil, controller.ErrDuplicateMember
+		default:
+			return nil, translateError(err)
+		}
+	}
+	return member, nil
+}
+
+// GetMembers retrieves the members of a project.
+func (c *Controller) GetMembers(ctx context.Context, projectID int64) ([]*model.Member, error) {
+	if err := c.authorize(ctx, authz.ActionRead, projectID); err != nil {
+		return nil, err
+	}
+	_, err := c.repo.Get(ctx, projectID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, controller.ErrNotFound
+		default:
+			return nil, translateError(err)
+		}
+	}
+	members, err := c.repo.GetMembers(ctx, projectID)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return members, nil
+}
+
+// UpdateMember changes the role of a project member. A project's last
+// owner cannot be given another role.
+func (c *Controller) UpdateMember(ctx context.Context, projectID, userID int64, role model.Role) (*model.Member, error) {
+	if err := c.authorizeMemberChange(ctx, projectID, role); err != nil {
+		return nil, err
+	}
+	var member *model.Member
+	// The last owner check and the change are made in one transaction, so that
+	// concurrent changes to the owners of a project cannot both pass the check.
+	err := c.repo.RunInTx(ctx, func(ctx context.Context) error {
+		if err := c.checkModifiable(ctx, projectID); err != nil {
+			return err
+		}
+		var err error
+		member, err = c.getMember(ctx, projectID, userID)
+		if err != nil {
+			return err
+		}
+		if err := c.authorizeMemberChange(ctx, projectID, member.Role); err != nil {
+			return err
+		}
+		if member.Role == model.RoleOwner && role != model.RoleOwner {
+			if err := c.checkLastOwner(ctx, projectID); err != nil {
+				return err
+			}
+		}
+		member.Role = role
+		v := validator.New()
+		if model.ValidateMember(v, member); !v.Valid() {
+			return controller.FailedValidation(v.Errors)
+		}
+		err = c.repo.UpdateMember(ctx, member)
+		if err != nil {
+			switch {
+			case errors.Is(err, repository.ErrNotFound):
+				return controller.ErrNotFound
+			default:
+				return translateError(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return member, nil
+}
+
+// RemoveMember removes a user from a project. A project's last owner cannot be removed.
+func (c *Controller) RemoveMember(ctx context.Context, projectID, userID int64) error {
+	if err := c.authorize(ctx, authz.ActionManageMembers, projectID); err != nil {
+		return err
+	}
+	// The last owner check and the removal are made in one transaction, as in UpdateMember.
+	err := c.repo.RunInTx(ctx, func(ctx context.Context) error {
+		if err := c.checkModifiable(ctx, projectID); err != nil {
+			return err
+		}
+		member, err := c.getMember(ctx, projectID, userID)
+		if err != nil {
+			return err
+		}
+		if err := c.authorizeMemberChange(ctx, projectID, member.Role); err != nil {
+			return err
+		}
+		if member.Role == model.RoleOwner {
+			if err := c.checkLastOwner(ctx, projectID); err != nil {
+				return err
+			}
+		}
+		err = c.repo.RemoveMember(ctx, projectID, userID)
+		if err != nil {
+			switch {
+			case errors.Is(err, repository.ErrNotFound):
+				return controller.ErrNotFound
+			default:
+				return translateError(err)
+			}
+		}
+		return nil
+	})
+	return translateError(err)
+}
+
+// getMember retrieves a project member, translating repository errors.
+func (c *Controller) getMember(ctx context.Context, projectID, userID int64) (*model.Member, error) {
+	member, err := c.repo.GetMember(ctx, projectID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, controller.ErrNotFound
+		default:
+			return nil, translateError(err)
+		}
+	}
+	return member, nil
+}
+
+// authorizeMemberChange returns ErrPermissionDenied unless the caller in the context
+// may manage project members with the given role. Only owners may manage owners.
+func (c *Controller) authorizeMemberChange(ctx context.Context, projectID int64, role model.Role) error {
+	if role == model.RoleOwner {
+		return c.authorize(ctx, authz.ActionManageOwners, projectID)
+	}
+	return c.authorize(ctx, authz.ActionManageMembers, projectID)
+}
+
+// checkModifiable returns an error unless a project exists and is not archived.
+func (c *Controller) checkModifiable(ctx context.Context, projectID int64) error {
+	project, err := c.repo.Get(ctx, projectID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return controller.ErrNotFound
+		default:
+			return translateError(err)
+		}
+	}
+	if project.ArchivedOn != nil {
+		return controller.ErrProjectArchived
+	}
+	return nil
+}
+
+// checkLastOwner returns ErrLastOwner if a project has no more than one owner.
+// Called in a transaction, it locks the owners of the project until the transaction ends.
+func (c *Controller) checkLastOwner(ctx context.Context, projectID int64) error {
+	owners, err := c.repo.CountOwners(ctx, projectID)
+	if err != nil {
+		return translateError(err)
+	}
+	if owners <= 1 {
+		return controller.ErrLastOwner
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/emzola/venato/project/internal/auth"
+	"github.com/emzola/venato/project/internal/authz"
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/pkg/model"
+)
+
+func TestLastOwner(t *testing.T) {
+	tests := []struct {
+		name    string
+		owners  int // the number of owners of the project, users 1 and up.
+		fn      func(c *Controller, id int64) error
+		wantErr error
+	}{
+		{"demote the only owner", 1, func(c *Controller, id int64) error {
+			_, err := c.UpdateMember(as(1), id, 1, model.RoleAdmin)
+			return err
+		}, controller.ErrLastOwner},
+		{"remove the only owner", 1, func(c *Controller, id int64) error {
+			return c.RemoveMember(as(1), id, 1)
+		}, controller.ErrLastOwner},
+		{"keep the only owner an owner", 1, func(c *Controller, id int64) error {
+			_, err := c.UpdateMember(as(1), id, 1, model.RoleOwner)
+			return err
+		}, nil},
+		{"demote one of two owners", 2, func(c *Controller, id int64) error {
+			_, err := c.UpdateMember(as(1), id, 2, model.RoleMember)
+			return err
+		}, nil},
+		{"remove one of two owners", 2, func(c *Controller, id int64) error {
+			return c.RemoveMember(as(2), id, 1)
+		}, nil},
+		{"demote both of two owners", 2, func(c *Controller, id int64) error {
+			if _, err := c.UpdateMember(as(1), id, 2, model.RoleMember); err != nil {
+				return err
+			}
+			_, err := c.UpdateMember(as(1), id, 1, model.RoleMember)
+			return err
+		}, controller.ErrLastOwner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(t)
+			project := mustCreate(t, c, "Apollo")
+			for userID := int64(2); userID <= int64(tt.owners); userID++ {
+				if _, err := c.AddMember(as(1), project.ID, userID, model.RoleOwner, 1); err != nil {
+					t.Fatalf("AddMember(%d): %v", userID, err)
+				}
+			}
+			if err := tt.fn(c, project.ID); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			admin := auth.NewContext(context.Background(), &auth.Principal{UserID: 9, Roles: []string{authz.AdminRole}})
+			members, err := c.GetMembers(admin, project.ID)
+			if err != nil {
+				t.Fatalf("GetMembers: %v", err)
+			}
+			owners := 0
+			for _, member := range members {
+				if member.Role == model.RoleOwner {
+					owners++
+				}
+			}
+			if owners == 0 {
+				t.Errorf("got members %+v, want at least one owner", members)
+			}
+		})
+	}
+}
+
+func TestLastOwnerConcurrent(t *testing.T) {
+	c := newTestController(t)
+	project := mustCreate(t, c, "Apollo")
+	if _, err := c.AddMember(as(1), project.ID, 2, model.RoleOwner, 1); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	// Each owner steps down at the same time; only one of them may succeed.
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, userID := range []int64{1, 2} {
+		wg.Add(1)
+		go func(i int, userID int64) {
+			defer wg.Done()
+			_, errs[i] = c.UpdateMember(as(userID), project.ID, userID, model.RoleAdmin)
+		}(i, userID)
+	}
+	wg.Wait()
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, controller.ErrLastOwner):
+			t.Errorf("got error %v, want ErrLastOwner", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("got %d owners stepping down, want 1", succeeded)
+	}
+}
+
+func TestMemberPermissions(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func(c *Controller, id int64) error
+		wantErr error
+	}{
+		{"admin adds a member", func(c *Controller, id int64) error {
+			_, err := c.AddMember(as(2), id, 4, model.RoleMember, 2)
+			return err
+		}, nil},
+		{"admin adds an owner", func(c *Controller, id int64) error {
+			_, err := c.AddMember(as(2), id, 4, model.RoleOwner, 2)
+			return err
+		}, controller.ErrPermissionDenied},
+		{"admin promotes a member to owner", func(c *Controller, id int64) error {
+			_, err := c.UpdateMember(as(2), id, 3, model.RoleOwner)
+			return err
+		}, controller.ErrPermissionDenied},
+		{"admin demotes an owner", func(c *Controller, id int64) error {
+			_, err := c.UpdateMember(as(2), id, 1, model.RoleMember)
+			return err
+		}, controller.ErrPermissionDenied},
+		{"admin removes an owner", func(c *Controller, id int64) error {
+			return c.RemoveMember(as(2), id, 1)
+		}, controller.ErrPermissionDenied},
+		{"member adds a member", func(c *Controller, id int64) error {
+			_, err := c.AddMember(as(3), id, 4, model.RoleViewer, 3)
+			return err
+		}, controller.ErrPermissionDenied},
+		{"owner adds an existing member", func(c *Controller, id int64) error {
+			_, err := c.AddMember(as(1), id, 3, model.RoleViewer, 1)
+			return err
+		}, controller.ErrDuplicateMember},
+		{"owner removes a user who is not a member", func(c *Controller, id int64) error {
+			return c.RemoveMember(as(1), id, 4)
+		}, controller.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(t)
+			project := mustCreate(t, c, "Apollo")
+			if _, err := c.AddMember(as(1), project.ID, 2, model.RoleAdmin, 1); err != nil {
+				t.Fatalf("AddMember: %v", err)
+			}
+			if _, err := c.AddMember(as(1), project.ID, 3, model.RoleMember, 1); err != nil {
+				t.Fatalf("AddMember: %v", err)
+			}
+			if err := tt.fn(c, project.ID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/emzola/venato/project/internal/authz"
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/emzola/venato/project/pkg/model"
+	"github.com/emzola/venato/project/pkg/validator"
+)
+
+// transitions holds the statuses a project may move to from each status.
+// Completed and cancelled projects are final.
+var transitions = map[model.ProjectStatus][]model.ProjectStatus{
+	model.StatusPlanned: {model.StatusActive, model.StatusCancelled},
+	model.StatusActive:  {model.StatusOnHold, model.StatusCompleted, model.StatusCancelled},
+	model.StatusOnHold:  {model.StatusActive, model.StatusCancelled},
+}
+
+// canTransition reports whether a project may move from one status to another.
+func canTransition(from, to model.ProjectStatus) bool {
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves a project to a new status. The actual end date of a project is
+// recorded when it moves to completed, defaulting to the current time when actualEndDate is nil.
+func (c *Controller) Transition(ctx context.Context, id int64, status model.ProjectStatus, actualEndDate *time.Time, modifiedBy int64) (*model.Project, error) {
+	if err := c.authorize(ctx, authz.ActionUpdate, id); err != nil {
+		return nil, err
+	}
+	v := validator.New()
+	model.ValidateStatus(v, status)
+	v.Check(actualEndDate == nil || status == model.StatusCompleted, "actual_end_date", "can only be set when completing a project")
+	if !v.Valid() {
+		return nil, controller.FailedValidation(v.Errors)
+	}
+	project, err := c.repo.Get(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return nil, controller.ErrNotFound
+		default:
+			return nil, translateError(err)
+		}
+	}
+	if project.ArchivedOn != nil {
+		return nil, controller.ErrProjectArchived
+	}
+	if !canTransition(project.Status, status) {
+		return nil, &controller.TransitionError{From: project.Status, To: status}
+	}
+	before := *project
+	project.Status = status
+	if status == model.StatusCompleted {
+		if actualEndDate == nil {
+			now := time.Now().UTC().Truncate(time.Second)
+			actualEndDate = &now
+		}
+		project.ActualEndDate = actualEndDate
+		v.Check(!project.ActualEndDate.Before(project.StartDate), "actual_end_date", "must not be before start date")
+	}
+	project.ModifiedBy = modifiedBy
+	if model.ValidateProject(v, project); !v.Valid() {
+		return nil, controller.FailedValidation(v.Errors)
+	}
+	err = c.update(ctx, model.AuditTransition, &before, project)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEditConflict):
+			return nil, controller.ErrEditConflict
+		default:
+			return nil, translateError(err)
+		}
+	}
+	return project, nil
+}
@@ -0,0 +1,121 @@
+package project
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/pkg/model"
+)
+
+// mustReach moves a new project to a status through the allowed transitions.
+func mustReach(t *testing.T, c *Controller, status model.ProjectStatus) *model.Project {
+	t.Helper()
+	paths := map[model.ProjectStatus][]model.ProjectStatus{
+		model.StatusPlanned:   nil,
+		model.StatusActive:    {model.StatusActive},
+		model.StatusOnHold:    {model.StatusActive, model.StatusOnHold},
+		model.StatusCompleted: {model.StatusActive, model.StatusCompleted},
+		model.StatusCancelled: {model.StatusCancelled},
+	}
+	project := mustCreate(t, c, "Apollo")
+	for _, next := range paths[status] {
+		var err error
+		project, err = c.Transition(as(1), project.ID, next, nil, 1)
+		if err != nil {
+			t.Fatalf("Transition to %s: %v", next, err)
+		}
+	}
+	return project
+}
+
+func TestTransition(t *testing.T) {
+	statuses := []model.ProjectStatus{model.StatusPlanned, model.StatusActive, model.StatusOnHold, model.StatusCompleted, model.StatusCancelled}
+	allowed := map[model.ProjectStatus][]model.ProjectStatus{
+		model.StatusPlanned: {model.StatusActive, model.StatusCancelled},
+		model.StatusActive:  {model.StatusOnHold, model.StatusCompleted, model.StatusCancelled},
+		model.StatusOnHold:  {model.StatusActive, model.StatusCancelled},
+	}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			t.Run(string(from)+"/"+string(to), func(t *testing.T) {
+				want := false
+				for _, status := range allowed[from] {
+					want = want || status == to
+				}
+				c := newTestController(t)
+				project := mustReach(t, c, from)
+				got, err := c.Transition(as(1), project.ID, to, nil, 1)
+				if !want {
+					var transitionErr *controller.TransitionError
+					if !errors.As(err, &transitionErr) || transitionErr.From != from || transitionErr.To != to {
+						t.Fatalf("got error %v, want a TransitionError from %s to %s", err, from, to)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Transition: %v", err)
+				}
+				if got.Status != to || got.Version != project.Version+1 {
+					t.Errorf("got project %+v, want status %s at version %d", got, to, project.Version+1)
+				}
+				if (got.ActualEndDate != nil) != (to == model.StatusCompleted) {
+					t.Errorf("got actual end date %v moving to %s, want it set only when completed", got.ActualEndDate, to)
+				}
+			})
+		}
+	}
+}
+
+func TestTransitionActualEndDate(t *testing.T) {
+	endDate := testStart.AddDate(0, 2, 0)
+	beforeStart := testStart.AddDate(0, 0, -1)
+	tests := []struct {
+		name          string
+		status        model.ProjectStatus
+		actualEndDate *time.Time
+		want          *time.Time
+		wantErr       bool
+	}{
+		{name: "defaults to now", status: model.StatusCompleted},
+		{name: "given date", status: model.StatusCompleted, actualEndDate: &endDate, want: &endDate},
+		{name: "before start date", status: model.StatusCompleted, actualEndDate: &beforeStart, wantErr: true},
+		{name: "without completing", status: model.StatusOnHold, actualEndDate: &endDate, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(t)
+			project := mustReach(t, c, model.StatusActive)
+			got, err := c.Transition(as(1), project.ID, tt.status, tt.actualEndDate, 1)
+			if tt.wantErr {
+				var validationErr *controller.ValidationError
+				if !errors.As(err, &validationErr) || validationErr.Errors["actual_end_date"] == "" {
+					t.Fatalf("got error %v, want a validation error for actual_end_date", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Transition: %v", err)
+			}
+			switch {
+			case got.ActualEndDate == nil:
+				t.Errorf("got no actual end date, want one")
+			case tt.want != nil && !got.ActualEndDate.Equal(*tt.want):
+				t.Errorf("got actual end date %v, want %v", *got.ActualEndDate, *tt.want)
+			case tt.want == nil && time.Since(*got.ActualEndDate) > time.Minute:
+				t.Errorf("got actual end date %v, want the current time", *got.ActualEndDate)
+			}
+		})
+	}
+}
+
+func TestTransitionInvalidStatus(t *testing.T) {
+	c := newTestController(t)
+	project := mustCreate(t, c, "Apollo")
+	_, err := c.Transition(as(1), project.ID, "finished", nil, 1)
+	var validationErr *controller.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Errors["status"] == "" {
+		t.Errorf("got error %v, want a validation error for status", err)
+	}
+}
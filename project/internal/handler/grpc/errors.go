@@ -1,18 +1,77 @@
 package grpc
 
 import (
+	"errors"
+	"sort"
+
+	"github.com/emzola/venato/project/internal/controller"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 var (
-	internalServerError = status.Error(codes.Internal, "the server encountered a problem and could not process your request")
-	notFoundError       = status.Error(codes.NotFound, "the requested resource could not be found")
-	nilRequestError     = status.Error(codes.InvalidArgument, "nil request")
-	editConflictError   = status.Error(codes.AlreadyExists, "unable to update the record due to an edit conflict, please try again")
+	internalServerError       = status.Error(codes.Internal, "the server encountered a problem and could not process your request")
+	notFoundError             = status.Error(codes.NotFound, "the requested resource could not be found")
+	nilRequestError           = status.Error(codes.InvalidArgument, "nil request")
+	editConflictError         = status.Error(codes.AlreadyExists, "unable to update the record due to an edit conflict, please try again")
+	versionMismatchError      = status.Error(codes.Aborted, "the project has changed since the expected version, please fetch it and try again")
+	projectArchivedError      = status.Error(codes.FailedPrecondition, "the project is archived and cannot be modified")
+	projectNotArchivedError   = status.Error(codes.FailedPrecondition, "the project is not archived")
+	duplicateMemberError      = status.Error(codes.AlreadyExists, "the user is already a member of the project")
+	lastOwnerError            = status.Error(codes.FailedPrecondition, "a project must retain at least one owner")
+	idempotencyKeyReusedError = status.Error(codes.FailedPrecondition, "the idempotency key has already been used for a different request")
+	snapshotUnavailableError  = status.Error(codes.FailedPrecondition, "the project version was recorded before snapshots were kept, so its details are unavailable")
+	unauthenticatedError      = status.Error(codes.Unauthenticated, "invalid or missing authentication token")
+	permissionDeniedError     = status.Error(codes.PermissionDenied, "you do not have the necessary permissions to access this resource")
+	conflictError             = status.Error(codes.FailedPrecondition, "the request conflicts with the current state of the resource")
+	timeoutError              = status.Error(codes.Unavailable, "the server could not complete your request in time, please try again")
 )
 
-// failedValidationError returns a failed validation error message.
+// failedValidationError returns a failed validation error carrying a
+// BadRequest detail with a violation for each field which failed validation.
 func (h *Handler) failedValidationError(err error) error {
-	return status.Error(codes.InvalidArgument, err.Error())
+	var validationErr *controller.ValidationError
+	if !errors.As(err, &validationErr) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	fields := make([]string, 0, len(validationErr.Errors))
+	for field := range validationErr.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	badRequest := &errdetails.BadRequest{}
+	for _, field := range fields {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: validationErr.Errors[field],
+		})
+	}
+	st, detailErr := status.New(codes.InvalidArgument, "failed validation").WithDetails(badRequest)
+	if detailErr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return st.Err()
+}
+
+// invalidTransitionError returns an invalid status transition error message.
+func (h *Handler) invalidTransitionError(err error) error {
+	return status.Error(codes.FailedPrecondition, err.Error())
+}
+
+// controllerError converts the errors which any controller operation may return
+// into a status error, falling back to an internal server error.
+func (h *Handler) controllerError(err error) error {
+	switch {
+	case errors.Is(err, controller.ErrFailedValidation):
+		return h.failedValidationError(err)
+	case errors.Is(err, controller.ErrEditConflict):
+		return editConflictError
+	case errors.Is(err, controller.ErrConflict):
+		return conflictError
+	case errors.Is(err, controller.ErrTimeout):
+		return timeoutError
+	default:
+		return internalServerError
+	}
 }
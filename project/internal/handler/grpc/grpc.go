@@ -3,11 +3,14 @@ package grpc
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/emzola/venato/gen"
 	"github.com/emzola/venato/project/internal/controller"
 	"github.com/emzola/venato/project/internal/controller/project"
 	"github.com/emzola/venato/project/pkg/model"
+	"github.com/emzola/venato/project/pkg/validator"
 )
 
 // Handler defines a Project gRPC handler.
@@ -26,42 +29,120 @@ func (h *Handler) CreateProject(ctx context.Context, req *gen.CreateProjectReque
 	if req == nil {
 		return nil, nilRequestError
 	}
-	var userID int64 = 1
-	project, err := h.ctrl.Create(ctx, req.Name, req.Description, req.StartDate.AsTime(), req.TargetEndDate.AsTime(), userID, userID)
+	principal, err := h.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	project, err := h.ctrl.Create(ctx, req.Name, req.Key, req.Description, req.StartDate.AsTime(), req.TargetEndDate.AsTime(), principal.UserID, principal.UserID, h.idempotencyKey(ctx))
 	if err != nil {
 		switch {
 		case errors.Is(err, controller.ErrFailedValidation):
 			return nil, h.failedValidationError(err)
+		case errors.Is(err, controller.ErrIdempotencyKeyReused):
+			return nil, idempotencyKeyReusedError
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
 		default:
-			return nil, internalServerError
+			return nil, h.controllerError(err)
 		}
 	}
 	return &gen.CreateProjectResponse{Project: model.ProjectToProto(project)}, nil
 }
 
-// GetProject returns the project for a given record.
+// GetProject returns the project for a given record, looked up by key when one is supplied.
 func (h *Handler) GetProject(ctx context.Context, req *gen.GetProjectRequest) (*gen.GetProjectResponse, error) {
 	if req == nil {
 		return nil, nilRequestError
 	}
-	id := req.ProjectId
-	if id < 1 {
-		return nil, notFoundError
+	var project *model.Project
+	var err error
+	if req.Key != "" {
+		project, err = h.ctrl.GetByKey(ctx, req.Key)
+	} else {
+		id := req.ProjectId
+		if id < 1 {
+			return nil, notFoundError
+		}
+		project, err = h.ctrl.Get(ctx, id)
 	}
-	project, err := h.ctrl.Get(ctx, id)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
 			return nil, nil
 		case errors.Is(err, controller.ErrNotFound):
 			return nil, notFoundError
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
 		default:
-			return nil, internalServerError
+			return nil, h.controllerError(err)
 		}
 	}
 	return &gen.GetProjectResponse{Project: model.ProjectToProto(project)}, nil
 }
 
+// GetAllProjects returns a paginated list of projects.
+func (h *Handler) GetAllProjects(ctx context.Context, req *gen.GetAllProjectsRequest) (*gen.GetAllProjectsResponse, error) {
+	if req == nil {
+		return nil, nilRequestError
+	}
+	// Relevance sorting does not support cursor pagination, so cursor searches default to id order.
+	defaultSort := "id"
+	if req.Search != "" && req.Cursor == nil {
+		defaultSort = "-relevance"
+	}
+	filters := readFilters(req.Page, req.PageSize, req.Sort, defaultSort, req.Cursor)
+	query := model.ProjectQuery{Name: req.Name, Search: req.Search, Trashed: req.Trashed, Archived: req.Archived}
+	projects, metadata, err := h.ctrl.GetAll(ctx, query, filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil, nil
+		case errors.Is(err, controller.ErrFailedValidation):
+			return nil, h.failedValidationError(err)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
+		default:
+			return nil, h.controllerError(err)
+		}
+	}
+	resp := &gen.GetAllProjectsResponse{Metadata: model.MetadataToProto(metadata)}
+	for _, project := range projects {
+		resp.Projects = append(resp.Projects, model.ProjectToProto(project))
+	}
+	return resp, nil
+}
+
+// SearchProjects returns a paginated list of projects matching a full-text search query.
+func (h *Handler) SearchProjects(ctx context.Context, req *gen.SearchProjectsRequest) (*gen.SearchProjectsResponse, error) {
+	if req == nil {
+		return nil, nilRequestError
+	}
+	// Relevance sorting does not support cursor pagination, so cursor searches default to id order.
+	defaultSort := "-relevance"
+	if req.Cursor != nil {
+		defaultSort = "id"
+	}
+	filters := readFilters(req.Page, req.PageSize, req.Sort, defaultSort, req.Cursor)
+	projects, metadata, err := h.ctrl.Search(ctx, req.Query, filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil, nil
+		case errors.Is(err, controller.ErrFailedValidation):
+			return nil, h.failedValidationError(err)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
+		default:
+			return nil, h.controllerError(err)
+		}
+	}
+	resp := &gen.SearchProjectsResponse{Metadata: model.MetadataToProto(metadata)}
+	for _, project := range projects {
+		resp.Projects = append(resp.Projects, model.ProjectToProto(project))
+	}
+	return resp, nil
+}
+
 // UpdateProject updates the project for a given record.
 func (h *Handler) UpdateProject(ctx context.Context, req *gen.UpdateProjectRequest) (*gen.UpdateProjectResponse, error) {
 	if req == nil {
@@ -71,26 +152,225 @@ func (h *Handler) UpdateProject(ctx context.Context, req *gen.UpdateProjectReque
 	if id < 1 {
 		return nil, notFoundError
 	}
-	var userID int64 = 1
-	startDate := req.StartDate.AsTime()
-	targetEndDate := req.TargetEndDate.AsTime()
-	actualEndDate := req.ActualEndDate.AsTime()
-	project, err := h.ctrl.Update(ctx, id, &req.Name, &req.Description, &startDate, &targetEndDate, &actualEndDate, userID)
+	principal, err := h.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	input, err := readUpdateMask(req)
+	if err != nil {
+		return nil, h.failedValidationError(err)
+	}
+	project, err := h.ctrl.Update(ctx, id, req.ExpectedVersion, input.name, input.description, input.startDate, input.targetEndDate, principal.UserID)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
 			return nil, nil
 		case errors.Is(err, controller.ErrFailedValidation):
 			return nil, h.failedValidationError(err)
+		case errors.Is(err, controller.ErrNotFound):
+			return nil, notFoundError
 		case errors.Is(err, controller.ErrEditConflict):
 			return nil, editConflictError
+		case errors.Is(err, controller.ErrVersionMismatch):
+			return nil, versionMismatchError
+		case errors.Is(err, controller.ErrProjectArchived):
+			return nil, projectArchivedError
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
 		default:
-			return nil, internalServerError
+			return nil, h.controllerError(err)
 		}
 	}
 	return &gen.UpdateProjectResponse{Project: model.ProjectToProto(project)}, nil
 }
 
+// updateInput holds the fields of an UpdateProjectRequest to apply. A nil field is left unchanged.
+type updateInput struct {
+	name          *string
+	description   *string
+	startDate     *time.Time
+	targetEndDate *time.Time
+}
+
+// readUpdateMask returns the fields of req named by its update mask. Without a mask
+// only the fields with a non-empty value are returned, and "*" returns all of them.
+// A mask naming no fields, or naming a date which is not set, is rejected.
+func readUpdateMask(req *gen.UpdateProjectRequest) (*updateInput, error) {
+	var input updateInput
+	paths := req.GetUpdateMask().GetPaths()
+	if req.UpdateMask != nil && len(paths) == 0 {
+		return nil, controller.FailedValidation(map[string]string{
+			"update_mask": "must name at least one field",
+		})
+	}
+	if req.UpdateMask == nil {
+		if req.Name != "" {
+			paths = append(paths, "name")
+		}
+		if req.Description != "" {
+			paths = append(paths, "description")
+		}
+		if req.StartDate != nil {
+			paths = append(paths, "start_date")
+		}
+		if req.TargetEndDate != nil {
+			paths = append(paths, "target_end_date")
+		}
+	}
+	v := validator.New()
+	for _, path := range paths {
+		switch path {
+		case "*":
+			for _, path := range []string{"name", "description", "start_date", "target_end_date"} {
+				input.set(v, req, path)
+			}
+		case "name", "description", "start_date", "target_end_date":
+			input.set(v, req, path)
+		default:
+			return nil, controller.FailedValidation(map[string]string{
+				"update_mask": fmt.Sprintf("unknown field path %q", path),
+			})
+		}
+	}
+	if !v.Valid() {
+		return nil, controller.FailedValidation(v.Errors)
+	}
+	return &input, nil
+}
+
+// set copies the field of req named by path into the input. Dates cannot be cleared,
+// so a date which is not set records a validation error instead of the zero time.
+func (input *updateInput) set(v *validator.Validator, req *gen.UpdateProjectRequest, path string) {
+	switch path {
+	case "name":
+		input.name = &req.Name
+	case "description":
+		input.description = &req.Description
+	case "start_date":
+		if req.StartDate == nil {
+			v.AddError("start_date", "must be provided")
+			return
+		}
+		startDate := req.StartDate.AsTime()
+		input.startDate = &startDate
+	case "target_end_date":
+		if req.TargetEndDate == nil {
+			v.AddError("target_end_date", "must be provided")
+			return
+		}
+		targetEndDate := req.TargetEndDate.AsTime()
+		input.targetEndDate = &targetEndDate
+	}
+}
+
+// TransitionProject moves the project for a given record to a new status.
+func (h *Handler) TransitionProject(ctx context.Context, req *gen.TransitionProjectRequest) (*gen.TransitionProjectResponse, error) {
+	if req == nil {
+		return nil, nilRequestError
+	}
+	id := req.ProjectId
+	if id < 1 {
+		return nil, notFoundError
+	}
+	principal, err := h.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var actualEndDate *time.Time
+	if req.ActualEndDate != nil {
+		t := req.ActualEndDate.AsTime()
+		actualEndDate = &t
+	}
+	project, err := h.ctrl.Transition(ctx, id, model.ProjectStatus(req.Status), actualEndDate, principal.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil, nil
+		case errors.Is(err, controller.ErrFailedValidation):
+			return nil, h.failedValidationError(err)
+		case errors.Is(err, controller.ErrNotFound):
+			return nil, notFoundError
+		case errors.Is(err, controller.ErrEditConflict):
+			return nil, editConflictError
+		case errors.Is(err, controller.ErrProjectArchived):
+			return nil, projectArchivedError
+		case errors.Is(err, controller.ErrInvalidTransition):
+			return nil, h.invalidTransitionError(err)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
+		default:
+			return nil, h.controllerError(err)
+		}
+	}
+	return &gen.TransitionProjectResponse{Project: model.ProjectToProto(project)}, nil
+}
+
+// ArchiveProject archives the project for a given record.
+func (h *Handler) ArchiveProject(ctx context.Context, req *gen.ArchiveProjectRequest) (*gen.ArchiveProjectResponse, error) {
+	if req == nil {
+		return nil, nilRequestError
+	}
+	id := req.ProjectId
+	if id < 1 {
+		return nil, notFoundError
+	}
+	principal, err := h.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	project, err := h.ctrl.Archive(ctx, id, principal.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil, nil
+		case errors.Is(err, controller.ErrNotFound):
+			return nil, notFoundError
+		case errors.Is(err, controller.ErrEditConflict):
+			return nil, editConflictError
+		case errors.Is(err, controller.ErrProjectArchived):
+			return nil, projectArchivedError
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
+		default:
+			return nil, h.controllerError(err)
+		}
+	}
+	return &gen.ArchiveProjectResponse{Project: model.ProjectToProto(project)}, nil
+}
+
+// UnarchiveProject unarchives the project for a given record.
+func (h *Handler) UnarchiveProject(ctx context.Context, req *gen.UnarchiveProjectRequest) (*gen.UnarchiveProjectResponse, error) {
+	if req == nil {
+		return nil, nilRequestError
+	}
+	id := req.ProjectId
+	if id < 1 {
+		return nil, notFoundError
+	}
+	principal, err := h.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	project, err := h.ctrl.Unarchive(ctx, id, principal.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil, nil
+		case errors.Is(err, controller.ErrNotFound):
+			return nil, notFoundError
+		case errors.Is(err, controller.ErrEditConflict):
+			return nil, editConflictError
+		case errors.Is(err, controller.ErrProjectNotArchived):
+			return nil, projectNotArchivedError
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
+		default:
+			return nil, h.controllerError(err)
+		}
+	}
+	return &gen.UnarchiveProjectResponse{Project: model.ProjectToProto(project)}, nil
+}
+
 // DeleteProject deletes the project for a given record.
 func (h *Handler) DeleteProject(ctx context.Context, req *gen.DeleteProjectRequest) (*gen.DeleteProjectResponse, error) {
 	if req == nil {
@@ -100,16 +380,76 @@ func (h *Handler) DeleteProject(ctx context.Context, req *gen.DeleteProjectReque
 	if id < 1 {
 		return nil, notFoundError
 	}
-	err := h.ctrl.Delete(ctx, id)
+	principal, err := h.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = h.ctrl.Delete(ctx, id, 0, principal.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil, nil
+		case errors.Is(err, controller.ErrNotFound):
+			return nil, notFoundError
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
+		default:
+			return nil, h.controllerError(err)
+		}
+	}
+	return &gen.DeleteProjectResponse{Message: "project successfully moved to trash"}, nil
+}
+
+// RestoreProject restores the project for a given record from the trash.
+func (h *Handler) RestoreProject(ctx context.Context, req *gen.RestoreProjectRequest) (*gen.RestoreProjectResponse, error) {
+	if req == nil {
+		return nil, nilRequestError
+	}
+	id := req.ProjectId
+	if id < 1 {
+		return nil, notFoundError
+	}
+	principal, err := h.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	project, err := h.ctrl.Restore(ctx, id, principal.UserID)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
 			return nil, nil
 		case errors.Is(err, controller.ErrNotFound):
 			return nil, notFoundError
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
 		default:
-			return nil, internalServerError
+			return nil, h.controllerError(err)
 		}
 	}
-	return &gen.DeleteProjectResponse{Message: "project successfully deleted"}, nil
+	return &gen.RestoreProjectResponse{Project: model.ProjectToProto(project)}, nil
+}
+
+// readFilters builds pagination filters from request values, falling back
+// to the first page of 20 records sorted by defaultSort when unset. Setting
+// the cursor, even to an empty string, selects keyset pagination.
+func readFilters(page, pageSize int32, sort, defaultSort string, cursor *string) model.Filters {
+	filters := model.Filters{
+		Page:     int(page),
+		PageSize: int(pageSize),
+		Sort:     sort,
+	}
+	if filters.Page == 0 {
+		filters.Page = 1
+	}
+	if filters.PageSize == 0 {
+		filters.PageSize = 20
+	}
+	if filters.Sort == "" {
+		filters.Sort = defaultSort
+	}
+	if cursor != nil {
+		filters.CursorMode = true
+		filters.Cursor = *cursor
+	}
+	return filters
 }
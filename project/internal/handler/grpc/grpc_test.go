@@ -0,0 +1,212 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/emzola/venato/gen"
+	"github.com/emzola/venato/project/internal/auth"
+	"github.com/emzola/venato/project/internal/authz"
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/internal/controller/project"
+	"github.com/emzola/venato/project/internal/repository/memory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestReadUpdateMask(t *testing.T) {
+	start := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(30 * 24 * time.Hour)
+	mask := func(paths ...string) *fieldmaskpb.FieldMask {
+		return &fieldmaskpb.FieldMask{Paths: paths}
+	}
+	tests := []struct {
+		name      string
+		req       *gen.UpdateProjectRequest
+		want      []string // the fields present in the input.
+		wantField string   // the field of the validation error, if any.
+	}{
+		{
+			name: "no mask with every field set",
+			req:  &gen.UpdateProjectRequest{Name: "Apollo", Description: "Moon", StartDate: timestamppb.New(start), TargetEndDate: timestamppb.New(end)},
+			want: []string{"name", "description", "start_date", "target_end_date"},
+		},
+		{
+			name: "no mask skips empty fields",
+			req:  &gen.UpdateProjectRequest{Name: "Apollo"},
+			want: []string{"name"},
+		},
+		{
+			name: "no mask and no fields",
+			req:  &gen.UpdateProjectRequest{},
+		},
+		{
+			name: "mask selects fields",
+			req:  &gen.UpdateProjectRequest{Name: "Apollo", Description: "Moon", UpdateMask: mask("description")},
+			want: []string{"description"},
+		},
+		{
+			name: "mask clears a field",
+			req:  &gen.UpdateProjectRequest{Name: "Apollo", UpdateMask: mask("name", "description")},
+			want: []string{"name", "description"},
+		},
+		{
+			name: "wildcard mask",
+			req:  &gen.UpdateProjectRequest{Name: "Apollo", StartDate: timestamppb.New(start), TargetEndDate: timestamppb.New(end), UpdateMask: mask("*")},
+			want: []string{"name", "description", "start_date", "target_end_date"},
+		},
+		{
+			name:      "empty mask",
+			req:       &gen.UpdateProjectRequest{Name: "Apollo", UpdateMask: mask()},
+			wantField: "update_mask",
+		},
+		{
+			name:      "unknown path",
+			req:       &gen.UpdateProjectRequest{Name: "Apollo", UpdateMask: mask("name", "status")},
+			wantField: "update_mask",
+		},
+		{
+			name:      "path with the JSON name",
+			req:       &gen.UpdateProjectRequest{StartDate: timestamppb.New(start), UpdateMask: mask("startDate")},
+			wantField: "update_mask",
+		},
+		{
+			name:      "mask names an unset start date",
+			req:       &gen.UpdateProjectRequest{Name: "Apollo", UpdateMask: mask("name", "start_date")},
+			wantField: "start_date",
+		},
+		{
+			name:      "mask names an unset target end date",
+			req:       &gen.UpdateProjectRequest{StartDate: timestamppb.New(start), UpdateMask: mask("start_date", "target_end_date")},
+			wantField: "target_end_date",
+		},
+		{
+			name:      "wildcard mask with an unset date",
+			req:       &gen.UpdateProjectRequest{Name: "Apollo", StartDate: timestamppb.New(start), UpdateMask: mask("*")},
+			wantField: "target_end_date",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := readUpdateMask(tt.req)
+			if tt.wantField != "" {
+				var validationErr *controller.ValidationError
+				if !errors.As(err, &validationErr) || len(validationErr.Errors) != 1 || validationErr.Errors[tt.wantField] == "" {
+					t.Fatalf("got error %v, want a validation error for %s", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readUpdateMask: %v", err)
+			}
+			got := map[string]bool{
+				"name":            input.name != nil,
+				"description":     input.description != nil,
+				"start_date":      input.startDate != nil,
+				"target_end_date": input.targetEndDate != nil,
+			}
+			for _, field := range tt.want {
+				if !got[field] {
+					t.Errorf("field %s is missing from the input", field)
+				}
+				delete(got, field)
+			}
+			for field, present := range got {
+				if present {
+					t.Errorf("field %s is unexpectedly in the input", field)
+				}
+			}
+			if input.name != nil && *input.name != tt.req.Name {
+				t.Errorf("got name %q, want %q", *input.name, tt.req.Name)
+			}
+			if input.startDate != nil && tt.req.StartDate != nil && !input.startDate.Equal(start) {
+				t.Errorf("got start date %v, want %v", *input.startDate, start)
+			}
+		})
+	}
+}
+
+func TestSearchWithCursor(t *testing.T) {
+	repo := memory.New()
+	h := New(project.New(repo, authz.NewRBAC(repo), time.Hour))
+	ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: 1})
+	start := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	for _, name := range []string{"Apollo 11", "Gemini", "Apollo 12", "Apollo 13"} {
+		if _, err := h.ctrl.Create(ctx, name, "", "", start, start.AddDate(0, 1, 0), 1, 1, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name  string
+		fetch func(cursor *string) ([]*gen.Project, *gen.Metadata, error)
+	}{
+		{
+			name: "GetAllProjects",
+			fetch: func(cursor *string) ([]*gen.Project, *gen.Metadata, error) {
+				resp, err := h.GetAllProjects(ctx, &gen.GetAllProjectsRequest{Search: "apollo", PageSize: 2, Cursor: cursor})
+				return resp.GetProjects(), resp.GetMetadata(), err
+			},
+		},
+		{
+			name: "SearchProjects",
+			fetch: func(cursor *string) ([]*gen.Project, *gen.Metadata, error) {
+				resp, err := h.SearchProjects(ctx, &gen.SearchProjectsRequest{Query: "apollo", PageSize: 2, Cursor: cursor})
+				return resp.GetProjects(), resp.GetMetadata(), err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			cursor := ""
+			for page := 0; page < 3; page++ {
+				projects, metadata, err := tt.fetch(&cursor)
+				if err != nil {
+					t.Fatalf("page %d: %v", page+1, err)
+				}
+				for _, project := range projects {
+					names = append(names, project.Name)
+				}
+				if metadata.GetNextCursor() == "" {
+					break
+				}
+				cursor = metadata.GetNextCursor()
+			}
+			want := []string{"Apollo 11", "Apollo 12", "Apollo 13"}
+			if len(names) != len(want) {
+				t.Fatalf("got projects %q, want %q", names, want)
+			}
+			for i := range want {
+				if names[i] != want[i] {
+					t.Fatalf("got projects %q in id order, want %q", names, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateProjectIdempotencyKeyReused(t *testing.T) {
+	repo := memory.New()
+	h := New(project.New(repo, authz.NewRBAC(repo), time.Hour))
+	ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: 1})
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("idempotency-key", "create-apollo"))
+	start := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	req := &gen.CreateProjectRequest{Name: "Apollo", StartDate: timestamppb.New(start), TargetEndDate: timestamppb.New(start.AddDate(0, 1, 0))}
+	first, err := h.CreateProject(ctx, req)
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	retried, err := h.CreateProject(ctx, req)
+	if err != nil || retried.Project.Id != first.Project.Id {
+		t.Fatalf("retried CreateProject = %v, %v; want project %d", retried, err, first.Project.Id)
+	}
+	req.Name = "Gemini"
+	if _, err := h.CreateProject(ctx, req); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("got error %v, want code FailedPrecondition", err)
+	}
+}
@@ -0,0 +1,117 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/emzola/venato/gen"
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/pkg/model"
+)
+
+// ListProjectHistory returns a paginated list of the changes made to the project for a given record.
+func (h *Handler) ListProjectHistory(ctx context.Context, req *gen.ListProjectHistoryRequest) (*gen.ListProjectHistoryResponse, error) {
+	if req == nil {
+		return nil, nilRequestError
+	}
+	id := req.ProjectId
+	if id < 1 {
+		return nil, notFoundError
+	}
+	filters := readFilters(req.Page, req.PageSize, req.Sort, "-version", nil)
+	entries, metadata, err := h.ctrl.History(ctx, id, filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil, nil
+		case errors.Is(err, controller.ErrFailedValidation):
+			return nil, h.failedValidationError(err)
+		case errors.Is(err, controller.ErrNotFound):
+			return nil, notFoundError
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
+		default:
+			return nil, h.controllerError(err)
+		}
+	}
+	resp := &gen.ListProjectHistoryResponse{Metadata: model.MetadataToProto(metadata)}
+	for _, entry := range entries {
+		resp.Entries = append(resp.Entries, model.AuditEntryToProto(entry))
+	}
+	return resp, nil
+}
+
+// GetProjectAtVersion returns the project for a given record as it was at a version or point in time.
+func (h *Handler) GetProjectAtVersion(ctx context.Context, req *gen.GetProjectAtVersionRequest) (*gen.GetProjectAtVersionResponse, error) {
+	if req == nil {
+		return nil, nilRequestError
+	}
+	id := req.ProjectId
+	if id < 1 {
+		return nil, notFoundError
+	}
+	var project *model.Project
+	var err error
+	switch at := req.At.(type) {
+	case *gen.GetProjectAtVersionRequest_Version:
+		project, err = h.ctrl.GetAtVersion(ctx, id, at.Version)
+	case *gen.GetProjectAtVersionRequest_Time:
+		project, err = h.ctrl.GetAt(ctx, id, at.Time.AsTime())
+	default:
+		return nil, h.failedValidationError(controller.FailedValidation(map[string]string{"at": "a version or time must be provided"}))
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil, nil
+		case errors.Is(err, controller.ErrNotFound):
+			return nil, notFoundError
+		case errors.Is(err, controller.ErrSnapshotUnavailable):
+			return nil, snapshotUnavailableError
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
+		default:
+			return nil, h.controllerError(err)
+		}
+	}
+	return &gen.GetProjectAtVersionResponse{Project: model.ProjectToProto(project)}, nil
+}
+
+// RevertProject gives the project for a given record back the details it had at an earlier version.
+func (h *Handler) RevertProject(ctx context.Context, req *gen.RevertProjectRequest) (*gen.RevertProjectResponse, error) {
+	if req == nil {
+		return nil, nilRequestError
+	}
+	id := req.ProjectId
+	if id < 1 {
+		return nil, notFoundError
+	}
+	principal, err := h.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	project, err := h.ctrl.Revert(ctx, id, req.Version, req.ExpectedVersion, principal.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil, nil
+		case errors.Is(err, controller.ErrFailedValidation):
+			return nil, h.failedValidationError(err)
+		case errors.Is(err, controller.ErrNotFound):
+			return nil, notFoundError
+		case errors.Is(err, controller.ErrSnapshotUnavailable):
+			return nil, snapshotUnavailableError
+		case errors.Is(err, controller.ErrEditConflict):
+			return nil, editConflictError
+		case errors.Is(err, controller.ErrVersionMismatch):
+			return nil, versionMismatchError
+		case errors.Is(err, controller.ErrProjectArchived):
+			return nil, projectArchivedError
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
+		default:
+			return nil, h.controllerError(err)
+		}
+	}
+	return &gen.RevertProjectResponse{Project: model.ProjectToProto(project)}, nil
+}
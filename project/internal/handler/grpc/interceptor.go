@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/emzola/venato/project/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// AuthInterceptor returns a unary server interceptor which verifies the bearer token
+// in the authorization metadata and stores the authenticated principal in the context.
+func AuthInterceptor(verifier *auth.Verifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) == 0 {
+			return nil, unauthenticatedError
+		}
+		token, ok := strings.CutPrefix(values[0], "Bearer ")
+		if !ok {
+			return nil, unauthenticatedError
+		}
+		principal, err := verifier.Verify(token)
+		if err != nil {
+			return nil, unauthenticatedError
+		}
+		return handler(auth.NewContext(ctx, principal), req)
+	}
+}
+
+// principal returns the authenticated caller stored in the context by AuthInterceptor.
+func (h *Handler) principal(ctx context.Context) (*auth.Principal, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, unauthenticatedError
+	}
+	return principal, nil
+}
+
+// idempotencyKey returns the idempotency key sent in the idempotency-key metadata, if any.
+func (h *Handler) idempotencyKey(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("idempotency-key")
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
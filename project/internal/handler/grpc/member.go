@@ -0,0 +1,134 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/emzola/venato/gen"
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/pkg/model"
+)
+
+// AddProjectMember adds a user to the project for a given record.
+func (h *Handler) AddProjectMember(ctx context.Context, req *gen.AddProjectMemberRequest) (*gen.AddProjectMemberResponse, error) {
+	if req == nil {
+		return nil, nilRequestError
+	}
+	id := req.ProjectId
+	if id < 1 {
+		return nil, notFoundError
+	}
+	principal, err := h.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	member, err := h.ctrl.AddMember(ctx, id, req.UserId, model.Role(req.Role), principal.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil, nil
+		case errors.Is(err, controller.ErrFailedValidation):
+			return nil, h.failedValidationError(err)
+		case errors.Is(err, controller.ErrNotFound):
+			return nil, notFoundError
+		case errors.Is(err, controller.ErrProjectArchived):
+			return nil, projectArchivedError
+		case errors.Is(err, controller.ErrDuplicateMember):
+			return nil, duplicateMemberError
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
+		default:
+			return nil, h.controllerError(err)
+		}
+	}
+	return &gen.AddProjectMemberResponse{Member: model.MemberToProto(member)}, nil
+}
+
+// ListProjectMembers returns the members of the project for a given record.
+func (h *Handler) ListProjectMembers(ctx context.Context, req *gen.ListProjectMembersRequest) (*gen.ListProjectMembersResponse, error) {
+	if req == nil {
+		return nil, nilRequestError
+	}
+	id := req.ProjectId
+	if id < 1 {
+		return nil, notFoundError
+	}
+	members, err := h.ctrl.GetMembers(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil, nil
+		case errors.Is(err, controller.ErrNotFound):
+			return nil, notFoundError
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
+		default:
+			return nil, h.controllerError(err)
+		}
+	}
+	resp := &gen.ListProjectMembersResponse{}
+	for _, member := range members {
+		resp.Members = append(resp.Members, model.MemberToProto(member))
+	}
+	return resp, nil
+}
+
+// UpdateProjectMember changes the role of a member of the project for a given record.
+func (h *Handler) UpdateProjectMember(ctx context.Context, req *gen.UpdateProjectMemberRequest) (*gen.UpdateProjectMemberResponse, error) {
+	if req == nil {
+		return nil, nilRequestError
+	}
+	id := req.ProjectId
+	if id < 1 {
+		return nil, notFoundError
+	}
+	member, err := h.ctrl.UpdateMember(ctx, id, req.UserId, model.Role(req.Role))
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil, nil
+		case errors.Is(err, controller.ErrFailedValidation):
+			return nil, h.failedValidationError(err)
+		case errors.Is(err, controller.ErrNotFound):
+			return nil, notFoundError
+		case errors.Is(err, controller.ErrProjectArchived):
+			return nil, projectArchivedError
+		case errors.Is(err, controller.ErrLastOwner):
+			return nil, lastOwnerError
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
+		default:
+			return nil, h.controllerError(err)
+		}
+	}
+	return &gen.UpdateProjectMemberResponse{Member: model.MemberToProto(member)}, nil
+}
+
+// RemoveProjectMember removes a user from the project for a given record.
+func (h *Handler) RemoveProjectMember(ctx context.Context, req *gen.RemoveProjectMemberRequest) (*gen.RemoveProjectMemberResponse, error) {
+	if req == nil {
+		return nil, nilRequestError
+	}
+	id := req.ProjectId
+	if id < 1 {
+		return nil, notFoundError
+	}
+	err := h.ctrl.RemoveMember(ctx, id, req.UserId)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil, nil
+		case errors.Is(err, controller.ErrNotFound):
+			return nil, notFoundError
+		case errors.Is(err, controller.ErrProjectArchived):
+			return nil, projectArchivedError
+		case errors.Is(err, controller.ErrLastOwner):
+			return nil, lastOwnerError
+		case errors.Is(err, controller.ErrPermissionDenied):
+			return nil, permissionDeniedError
+		default:
+			return nil, h.controllerError(err)
+		}
+	}
+	return &gen.RemoveProjectMemberResponse{Message: "member successfully removed"}, nil
+}
@@ -1,9 +1,11 @@
 package http
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/emzola/venato/project/internal/controller"
 	"go.uber.org/zap"
 )
 
@@ -50,6 +52,89 @@ func (h *Handler) editConflictResponse(w http.ResponseWriter, r *http.Request) {
 	h.errorResponse(w, r, http.StatusConflict, message)
 }
 
+func (h *Handler) preconditionFailedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the project has changed since the version given in the If-Match header"
+	h.errorResponse(w, r, http.StatusPreconditionFailed, message)
+}
+
+func (h *Handler) projectArchivedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the project is archived and cannot be modified"
+	h.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (h *Handler) projectNotArchivedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the project is not archived"
+	h.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (h *Handler) duplicateMemberResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the user is already a member of the project"
+	h.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (h *Handler) lastOwnerResponse(w http.ResponseWriter, r *http.Request) {
+	message := "a project must retain at least one owner"
+	h.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (h *Handler) snapshotUnavailableResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the project version was recorded before snapshots were kept, so its details are unavailable"
+	h.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (h *Handler) invalidAuthenticationTokenResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	message := "invalid or missing authentication token"
+	h.errorResponse(w, r, http.StatusUnauthorized, message)
+}
+
+func (h *Handler) notPermittedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "you do not have the necessary permissions to access this resource"
+	h.errorResponse(w, r, http.StatusForbidden, message)
+}
+
+// failedValidationResponse responds with the map of fields which failed validation and why.
 func (h *Handler) failedValidationResponse(w http.ResponseWriter, r *http.Request, err error) {
-	h.errorResponse(w, r, http.StatusUnprocessableEntity, err.Error())
+	var validationErr *controller.ValidationError
+	if !errors.As(err, &validationErr) {
+		h.errorResponse(w, r, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	h.errorResponse(w, r, http.StatusUnprocessableEntity, validationErr.Errors)
+}
+
+func (h *Handler) idempotencyKeyReusedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the idempotency key has already been used for a different request"
+	h.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (h *Handler) invalidTransitionResponse(w http.ResponseWriter, r *http.Request, err error) {
+	h.errorResponse(w, r, http.StatusConflict, err.Error())
+}
+
+func (h *Handler) conflictResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the request conflicts with the current state of the resource"
+	h.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (h *Handler) timeoutResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the server could not complete your request in time, please try again"
+	h.errorResponse(w, r, http.StatusServiceUnavailable, message)
+}
+
+// controllerErrorResponse responds to the errors which any controller operation
+// may return, falling back to a server error.
+func (h *Handler) controllerErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, controller.ErrFailedValidation):
+		h.failedValidationResponse(w, r, err)
+	case errors.Is(err, controller.ErrEditConflict):
+		h.editConflictResponse(w, r)
+	case errors.Is(err, controller.ErrConflict):
+		h.conflictResponse(w, r)
+	case errors.Is(err, controller.ErrTimeout):
+		h.timeoutResponse(w, r)
+	default:
+		h.serverErrorResponse(w, r, err)
+	}
 }
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +11,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/pkg/model"
 	"github.com/emzola/venato/project/pkg/validator"
 	"github.com/julienschmidt/httprouter"
 )
@@ -27,6 +30,124 @@ func (h *Handler) readIDParam(r *http.Request, idParam string) (int64, error) {
 	return id, nil
 }
 
+// readParam pulls a url parameter from the request and returns it.
+func (h *Handler) readParam(r *http.Request, param string) string {
+	params := httprouter.ParamsFromContext(r.Context())
+	return params.ByName(param)
+}
+
+// etag returns the entity tag of a project, which changes with its version.
+func (h *Handler) etag(project *model.Project) string {
+	return strconv.Quote(strconv.FormatInt(project.Version, 10))
+}
+
+// entityTag defines an entity tag read from a request header.
+type entityTag struct {
+	opaque string // the tag without its quotes.
+	weak   bool
+}
+
+// readEntityTags parses a comma-separated list of entity tags, as sent in the
+// If-Match and If-None-Match headers. Empty list elements are skipped.
+func (h *Handler) readEntityTags(value string) ([]entityTag, error) {
+	var tags []entityTag
+	for {
+		value = strings.TrimLeft(value, " \t,")
+		if value == "" {
+			return tags, nil
+		}
+		var tag entityTag
+		if strings.HasPrefix(value, "W/") {
+			tag.weak = true
+			value = value[2:]
+		}
+		if !strings.HasPrefix(value, `"`) {
+			return nil, errors.New("entity tags must be quoted")
+		}
+		end := strings.IndexByte(value[1:], '"')
+		if end < 0 {
+			return nil, errors.New("entity tags must be quoted")
+		}
+		tag.opaque = value[1 : end+1]
+		tags = append(tags, tag)
+		value = strings.TrimLeft(value[end+2:], " \t")
+		if value != "" && value[0] != ',' {
+			return nil, errors.New("entity tags must be separated by commas")
+		}
+	}
+}
+
+// readIfMatch returns the project versions named by the If-Match header of the request,
+// or nil if the header is absent or matches any version. If-Match compares entity tags
+// strongly, so weak tags, and tags which do not name a version, never match. A header
+// holding only such tags returns an empty slice, which no version of the project matches.
+func (h *Handler) readIfMatch(r *http.Request) ([]int64, error) {
+	value := strings.TrimSpace(strings.Join(r.Header.Values("If-Match"), ","))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+	tags, err := h.readEntityTags(value)
+	if err != nil {
+		return nil, fmt.Errorf("the If-Match header must contain a list of entity tags: %w", err)
+	}
+	versions := []int64{}
+	for _, tag := range tags {
+		if tag.weak {
+			continue
+		}
+		version, err := strconv.ParseInt(tag.opaque, 10, 64)
+		if err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
+
+// expectedVersion returns the version a project must still be at for a request made with
+// the If-Match versions to proceed, or zero if any version will do. When several versions
+// are named, it looks up the project to find out which of them it is at. It returns
+// ErrVersionMismatch if the project can be at none of the versions.
+func (h *Handler) expectedVersion(ctx context.Context, id int64, versions []int64) (int64, error) {
+	switch {
+	case versions == nil:
+		return 0, nil
+	case len(versions) == 0:
+		return 0, controller.ErrVersionMismatch
+	case len(versions) == 1:
+		return versions[0], nil
+	}
+	project, err := h.ctrl.Get(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	for _, version := range versions {
+		if version == project.Version {
+			return version, nil
+		}
+	}
+	return 0, controller.ErrVersionMismatch
+}
+
+// ifNoneMatch reports whether the If-None-Match header of the request matches
+// the entity tag, so that the client's cached copy of the resource is still current.
+// If-None-Match compares entity tags weakly, so a weak tag matches too.
+func (h *Handler) ifNoneMatch(r *http.Request, etag string) bool {
+	value := strings.TrimSpace(strings.Join(r.Header.Values("If-None-Match"), ","))
+	if value == "*" {
+		return true
+	}
+	tags, err := h.readEntityTags(value)
+	if err != nil {
+		return false
+	}
+	for _, tag := range tags {
+		if `"`+tag.opaque+`"` == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // readString returns a string value from the query string, or the provided
 // default value if no matching key could be found.
 func (h *Handler) readString(qs url.Values, key string, defaultValue string) string {
@@ -54,6 +175,23 @@ func (h *Handler) readInt(qs url.Values, key string, defaultValue int, v *valida
 	return i
 }
 
+// readBool reads a string value from the query string and converts it to a
+// boolean before returning. If no matching key could be found it returns the provided
+// default value. If the value couldn't be converted to a boolean, it records an
+// error message in the provided Validator instance.
+func (h *Handler) readBool(qs url.Values, key string, defaultValue bool, v *validator.Validator) bool {
+	s := qs.Get(key)
+	if len(s) == 0 {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddError(key, "must be a boolean value")
+		return defaultValue
+	}
+	return b
+}
+
 // encodeJSON serializes data to JSON and writes the appropriate HTTP status code and headers if necessary.
 func (h *Handler) encodeJSON(w http.ResponseWriter, status int, data envelop, headers http.Header) error {
 	js, err := json.MarshalIndent(data, "", "\t")
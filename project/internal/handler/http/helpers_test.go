@@ -0,0 +1,198 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emzola/venato/project/internal/auth"
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/pkg/model"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestETag(t *testing.T) {
+	h := &Handler{}
+	if got := h.etag(&model.Project{Version: 7}); got != `"7"` {
+		t.Errorf("got entity tag %s, want \"7\"", got)
+	}
+}
+
+func TestReadIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []string
+		want    []int64 // nil when any version matches.
+		wantErr bool
+	}{
+		{name: "absent"},
+		{name: "empty", header: []string{""}},
+		{name: "any version", header: []string{"*"}},
+		{name: "version", header: []string{`"3"`}, want: []int64{3}},
+		{name: "surrounding spaces", header: []string{` "3" `}, want: []int64{3}},
+		{name: "list", header: []string{`"3", "4"`}, want: []int64{3, 4}},
+		{name: "list without spaces", header: []string{`"3","4"`}, want: []int64{3, 4}},
+		{name: "list over several headers", header: []string{`"3"`, `"4"`}, want: []int64{3, 4}},
+		{name: "list with empty elements", header: []string{`, "3",, "4",`}, want: []int64{3, 4}},
+		{name: "weak tag", header: []string{`W/"3"`}, want: []int64{}},
+		{name: "weak and strong tags", header: []string{`W/"3", "4"`}, want: []int64{4}},
+		{name: "not a version", header: []string{`"abc"`}, want: []int64{}},
+		{name: "zero version", header: []string{`"0"`}, want: []int64{}},
+		{name: "negative version", header: []string{`"-1"`}, want: []int64{}},
+		{name: "version too large", header: []string{`"9223372036854775808"`}, want: []int64{}},
+		{name: "tag with a comma", header: []string{`"3,4", "5"`}, want: []int64{5}},
+		{name: "unquoted", header: []string{"3"}, wantErr: true},
+		{name: "unterminated", header: []string{`"3`}, wantErr: true},
+		{name: "missing comma", header: []string{`"3" "4"`}, wantErr: true},
+		{name: "lowercase weak prefix", header: []string{`w/"3"`}, wantErr: true},
+	}
+	h := &Handler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, "/projects/1", nil)
+			for _, value := range tt.header {
+				r.Header.Add("If-Match", value)
+			}
+			got, err := h.readIfMatch(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got versions %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIfNoneMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"absent", "", false},
+		{"any version", "*", true},
+		{"current version", `"3"`, true},
+		{"weak current version", `W/"3"`, true},
+		{"older version", `"2"`, false},
+		{"unquoted current version", "3", false},
+		{"list with the current version", `"1", "2" ,"3"`, true},
+		{"list without the current version", `"1", "2"`, false},
+		{"list with the weak current version", `"1", W/"3"`, true},
+		{"malformed list", `"1" "3"`, false},
+	}
+	h := &Handler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/projects/1", nil)
+			if tt.header != "" {
+				r.Header.Set("If-None-Match", tt.header)
+			}
+			if got := h.ifNoneMatch(r, `"3"`); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// versionedController serves a single project at version 3.
+type versionedController struct {
+	projectController
+}
+
+func (c versionedController) project() *model.Project {
+	return &model.Project{ID: 1, Name: "Apollo", Key: "APL", Version: 3}
+}
+
+func (c versionedController) Get(ctx context.Context, id int64) (*model.Project, error) {
+	return c.project(), nil
+}
+
+func (c versionedController) Update(ctx context.Context, id, expectedVersion int64, name, description *string, startDate, targetEndDate *time.Time, modifiedBy int64) (*model.Project, error) {
+	if expectedVersion != 0 && expectedVersion != 3 {
+		return nil, controller.ErrVersionMismatch
+	}
+	project := c.project()
+	project.Version++
+	return project, nil
+}
+
+func (c versionedController) Delete(ctx context.Context, id, expectedVersion int64, deletedBy int64) error {
+	if expectedVersion != 0 && expectedVersion != 3 {
+		return controller.ErrVersionMismatch
+	}
+	return nil
+}
+
+func TestConditionalRequests(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	verifier, err := auth.NewVerifier(secret, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   "1",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := New(versionedController{}, verifier).Routes()
+	tests := []struct {
+		name       string
+		method     string
+		header     string
+		value      string
+		wantStatus int
+		wantETag   string
+		wantNoBody bool
+	}{
+		{"get", http.MethodGet, "", "", http.StatusOK, `"3"`, false},
+		{"get current", http.MethodGet, "If-None-Match", `"3"`, http.StatusNotModified, `"3"`, true},
+		{"get stale", http.MethodGet, "If-None-Match", `"2"`, http.StatusOK, `"3"`, false},
+		{"update", http.MethodPatch, "", "", http.StatusOK, `"4"`, false},
+		{"update current", http.MethodPatch, "If-Match", `"3"`, http.StatusOK, `"4"`, false},
+		{"update any", http.MethodPatch, "If-Match", "*", http.StatusOK, `"4"`, false},
+		{"update list with the current version", http.MethodPatch, "If-Match", `"2", "3"`, http.StatusOK, `"4"`, false},
+		{"update stale", http.MethodPatch, "If-Match", `"2"`, http.StatusPreconditionFailed, "", false},
+		{"update list of stale versions", http.MethodPatch, "If-Match", `"1", "2"`, http.StatusPreconditionFailed, "", false},
+		{"update weak current version", http.MethodPatch, "If-Match", `W/"3"`, http.StatusPreconditionFailed, "", false},
+		{"update unknown tag", http.MethodPatch, "If-Match", `"three"`, http.StatusPreconditionFailed, "", false},
+		{"update malformed", http.MethodPatch, "If-Match", "2", http.StatusBadRequest, "", false},
+		{"delete", http.MethodDelete, "If-Match", `"3"`, http.StatusOK, "", false},
+		{"delete list with the current version", http.MethodDelete, "If-Match", `"3", "4"`, http.StatusOK, "", false},
+		{"delete stale", http.MethodDelete, "If-Match", `"2"`, http.StatusPreconditionFailed, "", false},
+		{"delete weak current version", http.MethodDelete, "If-Match", `W/"3"`, http.StatusPreconditionFailed, "", false},
+		{"delete malformed", http.MethodDelete, "If-Match", `"two`, http.StatusBadRequest, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body *strings.Reader
+			if tt.method == http.MethodPatch {
+				body = strings.NewReader(`{"name": "Apollo 11"}`)
+			} else {
+				body = strings.NewReader("")
+			}
+			r := httptest.NewRequest(tt.method, "/projects/1", body)
+			r.Header.Set("Authorization", "Bearer "+token)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			routes.ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if got := w.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("got ETag %q, want %q", got, tt.wantETag)
+			}
+			if tt.wantNoBody && w.Body.Len() != 0 {
+				t.Errorf("got body %q for a not modified response, want none", w.Body)
+			}
+		})
+	}
+}
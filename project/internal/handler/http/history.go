@@ -0,0 +1,162 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/pkg/model"
+	"github.com/emzola/venato/project/pkg/validator"
+)
+
+// getProjectHistory handles GET /projects/:id/history requests for retrieving a paginated list of the changes made to a project.
+func (h *Handler) getProjectHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := h.readIDParam(r, "id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	var filters model.Filters
+	v := validator.New()
+	qs := r.URL.Query()
+	filters.Page = h.readInt(qs, "page", 1, v)
+	filters.PageSize = h.readInt(qs, "page_size", 20, v)
+	filters.Sort = h.readString(qs, "sort", "-version")
+	if !v.Valid() {
+		h.failedValidationResponse(w, r, controller.FailedValidation(v.Errors))
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	entries, metadata, err := h.ctrl.History(ctx, id, filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, controller.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, controller.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
+		default:
+			h.controllerErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"history": entries, "metadata": metadata}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// getProjectSnapshot handles GET /projects/:id/snapshot requests for retrieving a project as it was
+// at the version given by the version query parameter, or at the RFC 3339 time given by the at parameter.
+func (h *Handler) getProjectSnapshot(w http.ResponseWriter, r *http.Request) {
+	id, err := h.readIDParam(r, "id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	v := validator.New()
+	qs := r.URL.Query()
+	version := h.readInt(qs, "version", 0, v)
+	var at time.Time
+	if qs.Has("at") {
+		at, err = time.Parse(time.RFC3339, qs.Get("at"))
+		v.Check(err == nil, "at", "must be an RFC 3339 time")
+	}
+	v.Check(qs.Has("version") != qs.Has("at"), "version", "exactly one of version and at must be provided")
+	if !v.Valid() {
+		h.failedValidationResponse(w, r, controller.FailedValidation(v.Errors))
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	var project *model.Project
+	if qs.Has("at") {
+		project, err = h.ctrl.GetAt(ctx, id, at)
+	} else {
+		project, err = h.ctrl.GetAtVersion(ctx, id, int64(version))
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, controller.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, controller.ErrSnapshotUnavailable):
+			h.snapshotUnavailableResponse(w, r)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
+		default:
+			h.controllerErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"project": project}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// revertProject handles POST /projects/:id/revert requests for giving a project back the details it had at an earlier version.
+func (h *Handler) revertProject(w http.ResponseWriter, r *http.Request) {
+	id, err := h.readIDParam(r, "id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	var requestBody struct {
+		Version int64 `json:"version"`
+	}
+	ifMatch, err := h.readIfMatch(r)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	err = h.decodeJSON(w, r, &requestBody)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	principal := h.principal(r)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	var project *model.Project
+	expectedVersion, err := h.expectedVersion(ctx, id, ifMatch)
+	if err == nil {
+		project, err = h.ctrl.Revert(ctx, id, requestBody.Version, expectedVersion, principal.UserID)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, controller.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, controller.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, controller.ErrSnapshotUnavailable):
+			h.snapshotUnavailableResponse(w, r)
+		case errors.Is(err, controller.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		case errors.Is(err, controller.ErrVersionMismatch):
+			h.preconditionFailedResponse(w, r)
+		case errors.Is(err, controller.ErrProjectArchived):
+			h.projectArchivedResponse(w, r)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
+		default:
+			h.controllerErrorResponse(w, r, err)
+		}
+		return
+	}
+	header := make(http.Header)
+	header.Set("ETag", h.etag(project))
+	err = h.encodeJSON(w, http.StatusOK, envelop{"project": project}, header)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
@@ -7,33 +7,49 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/emzola/venato/project/internal/auth"
 	"github.com/emzola/venato/project/internal/controller"
 	"github.com/emzola/venato/project/pkg/model"
 	"github.com/emzola/venato/project/pkg/validator"
 )
 
 type projectController interface {
-	Create(ctx context.Context, name, description string, startDate, targetEndDate time.Time, createdBy, modifiedBy int64) (*model.Project, error)
+	Create(ctx context.Context, name, key, description string, startDate, targetEndDate time.Time, createdBy, modifiedBy int64, idempotencyKey string) (*model.Project, error)
 	Get(ctx context.Context, id int64) (*model.Project, error)
-	// GetAll(ctx context.Context) ([]*model.Project, model.Metadata, error)
-	Update(ctx context.Context, id int64, name, description *string, startDate, targetEndDate, actualEndDate *time.Time, modifiedBy int64) (*model.Project, error)
-	Delete(ctx context.Context, id int64) error
+	GetByKey(ctx context.Context, key string) (*model.Project, error)
+	GetAll(ctx context.Context, query model.ProjectQuery, filters model.Filters) ([]*model.Project, model.Metadata, error)
+	Update(ctx context.Context, id, expectedVersion int64, name, description *string, startDate, targetEndDate *time.Time, modifiedBy int64) (*model.Project, error)
+	Transition(ctx context.Context, id int64, status model.ProjectStatus, actualEndDate *time.Time, modifiedBy int64) (*model.Project, error)
+	Delete(ctx context.Context, id, expectedVersion int64, deletedBy int64) error
+	Restore(ctx context.Context, id int64, restoredBy int64) (*model.Project, error)
+	Archive(ctx context.Context, id int64, archivedBy int64) (*model.Project, error)
+	Unarchive(ctx context.Context, id int64, unarchivedBy int64) (*model.Project, error)
+	History(ctx context.Context, id int64, filters model.Filters) ([]*model.AuditEntry, model.Metadata, error)
+	GetAtVersion(ctx context.Context, id, version int64) (*model.Project, error)
+	GetAt(ctx context.Context, id int64, at time.Time) (*model.Project, error)
+	Revert(ctx context.Context, id, version, expectedVersion int64, modifiedBy int64) (*model.Project, error)
+	AddMember(ctx context.Context, projectID, userID int64, role model.Role, addedBy int64) (*model.Member, error)
+	GetMembers(ctx context.Context, projectID int64) ([]*model.Member, error)
+	UpdateMember(ctx context.Context, projectID, userID int64, role model.Role) (*model.Member, error)
+	RemoveMember(ctx context.Context, projectID, userID int64) error
 }
 
 // Handler defines a project HTTP handler.
 type Handler struct {
-	ctrl projectController
+	ctrl     projectController
+	verifier *auth.Verifier
 }
 
 // New creates a new project HTTP handler.
-func New(ctrl projectController) *Handler {
-	return &Handler{ctrl}
+func New(ctrl projectController, verifier *auth.Verifier) *Handler {
+	return &Handler{ctrl, verifier}
 }
 
 // createProject handles POST /projects requests for creating a new project.
 func (h *Handler) createProject(w http.ResponseWriter, r *http.Request) {
 	var requestBody struct {
 		Name          string    `json:"name"`
+		Key           string    `json:"key"`
 		Description   string    `json:"description"`
 		StartDate     time.Time `json:"start_date"`
 		TargetEndDate time.Time `json:"target_end_date"`
@@ -43,15 +59,20 @@ func (h *Handler) createProject(w http.ResponseWriter, r *http.Request) {
 		h.badRequestResponse(w, r, err)
 		return
 	}
+	principal := h.principal(r)
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
-	project, err := h.ctrl.Create(ctx, requestBody.Name, requestBody.Description, requestBody.StartDate, requestBody.TargetEndDate, 1, 1)
+	project, err := h.ctrl.Create(ctx, requestBody.Name, requestBody.Key, requestBody.Description, requestBody.StartDate, requestBody.TargetEndDate, principal.UserID, principal.UserID, r.Header.Get("Idempotency-Key"))
 	if err != nil {
 		switch {
 		case errors.Is(err, controller.ErrFailedValidation):
 			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, controller.ErrIdempotencyKeyReused):
+			h.idempotencyKeyReusedResponse(w, r)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
 		default:
-			h.serverErrorResponse(w, r, err)
+			h.controllerErrorResponse(w, r, err)
 		}
 		return
 	}
@@ -63,28 +84,39 @@ func (h *Handler) createProject(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// getProject handles GET /projects requests for retrieving a project.
+// getProject handles GET /projects requests for retrieving a project by its id or key.
 func (h *Handler) getProject(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	var project *model.Project
 	id, err := h.readIDParam(r, "id")
 	if err != nil {
-		h.badRequestResponse(w, r, err)
-		return
+		project, err = h.ctrl.GetByKey(ctx, h.readParam(r, "id"))
+	} else {
+		project, err = h.ctrl.Get(ctx, id)
 	}
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	project, err := h.ctrl.Get(ctx, id)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
 			return
 		case errors.Is(err, controller.ErrNotFound):
 			h.notFoundResponse(w, r)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
 		default:
-			h.serverErrorResponse(w, r, err)
+			h.controllerErrorResponse(w, r, err)
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusOK, envelop{"project": project}, nil)
+	etag := h.etag(project)
+	if h.ifNoneMatch(r, etag) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	header := make(http.Header)
+	header.Set("ETag", etag)
+	err = h.encodeJSON(w, http.StatusOK, envelop{"project": project}, header)
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
@@ -93,19 +125,52 @@ func (h *Handler) getProject(w http.ResponseWriter, r *http.Request) {
 // getAllProjects handles GET /projects requests for retrieving a paginated list of all projects.
 func (h *Handler) getAllProjects(w http.ResponseWriter, r *http.Request) {
 	var input struct {
-		Name string
+		model.ProjectQuery
 		model.Filters
 	}
 	v := validator.New()
 	qs := r.URL.Query()
 	input.Name = h.readString(qs, "name", "")
+	input.Search = h.readString(qs, "search", "")
+	input.Trashed = h.readBool(qs, "trashed", false, v)
+	input.Archived = h.readBool(qs, "archived", false, v)
 	input.Filters.Page = h.readInt(qs, "page", 1, v)
 	input.Filters.PageSize = h.readInt(qs, "page_size", 20, v)
-	input.Filters.Sort = h.readString(qs, "sort", "id")
-	input.Filters.SortSafelist = []string{"id", "name", "created_on", "modified_on", "-id", "-name", "-created_on", "- modified_on"}
-	// if !v.Valid() {
-	// 	h.failedValidationResponse(w, r, v.Errors)
-	// }
+	// Relevance sorting does not support cursor pagination, so cursor searches default to id order.
+	if input.Search != "" && !qs.Has("cursor") {
+		input.Filters.Sort = h.readString(qs, "sort", "-relevance")
+	} else {
+		input.Filters.Sort = h.readString(qs, "sort", "id")
+	}
+	// The presence of a cursor parameter, even an empty one, selects keyset pagination.
+	if qs.Has("cursor") {
+		input.Filters.CursorMode = true
+		input.Filters.Cursor = qs.Get("cursor")
+	}
+	if !v.Valid() {
+		h.failedValidationResponse(w, r, controller.FailedValidation(v.Errors))
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	projects, metadata, err := h.ctrl.GetAll(ctx, input.ProjectQuery, input.Filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, controller.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
+		default:
+			h.controllerErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"projects": projects, "metadata": metadata}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
 }
 
 // updateProject handles PATCH /projects requests for updating a project.
@@ -120,26 +185,160 @@ func (h *Handler) updateProject(w http.ResponseWriter, r *http.Request) {
 		Description   *string    `json:"description"`
 		StartDate     *time.Time `json:"start_date"`
 		TargetEndDate *time.Time `json:"target_end_date"`
-		ActualEndDate *time.Time `json:"actual_end_date,omitempty"`
+	}
+	ifMatch, err := h.readIfMatch(r)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	err = h.decodeJSON(w, r, &requestBody)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	principal := h.principal(r)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	var project *model.Project
+	expectedVersion, err := h.expectedVersion(ctx, id, ifMatch)
+	if err == nil {
+		project, err = h.ctrl.Update(ctx, id, expectedVersion, requestBody.Name, requestBody.Description, requestBody.StartDate, requestBody.TargetEndDate, principal.UserID)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, controller.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, controller.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, controller.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		case errors.Is(err, controller.ErrVersionMismatch):
+			h.preconditionFailedResponse(w, r)
+		case errors.Is(err, controller.ErrProjectArchived):
+			h.projectArchivedResponse(w, r)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
+		default:
+			h.controllerErrorResponse(w, r, err)
+		}
+		return
+	}
+	header := make(http.Header)
+	header.Set("ETag", h.etag(project))
+	err = h.encodeJSON(w, http.StatusOK, envelop{"project": project}, header)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// transitionProject handles POST /projects/:id/transition requests for changing the status of a project.
+func (h *Handler) transitionProject(w http.ResponseWriter, r *http.Request) {
+	id, err := h.readIDParam(r, "id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	var requestBody struct {
+		Status        model.ProjectStatus `json:"status"`
+		ActualEndDate *time.Time          `json:"actual_end_date"`
 	}
 	err = h.decodeJSON(w, r, &requestBody)
 	if err != nil {
 		h.badRequestResponse(w, r, err)
 		return
 	}
+	principal := h.principal(r)
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
-	project, err := h.ctrl.Update(ctx, id, requestBody.Name, requestBody.Description, requestBody.StartDate, requestBody.TargetEndDate, requestBody.ActualEndDate, 1)
+	project, err := h.ctrl.Transition(ctx, id, requestBody.Status, requestBody.ActualEndDate, principal.UserID)
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
 			return
 		case errors.Is(err, controller.ErrFailedValidation):
 			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, controller.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, controller.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		case errors.Is(err, controller.ErrProjectArchived):
+			h.projectArchivedResponse(w, r)
+		case errors.Is(err, controller.ErrInvalidTransition):
+			h.invalidTransitionResponse(w, r, err)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
+		default:
+			h.controllerErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"project": project}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// archiveProject handles POST /projects/:id/archive requests for archiving a project.
+func (h *Handler) archiveProject(w http.ResponseWriter, r *http.Request) {
+	id, err := h.readIDParam(r, "id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	principal := h.principal(r)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	project, err := h.ctrl.Archive(ctx, id, principal.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, controller.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, controller.ErrEditConflict):
+			h.editConflictResponse(w, r)
+		case errors.Is(err, controller.ErrProjectArchived):
+			h.projectArchivedResponse(w, r)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
+		default:
+			h.controllerErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"project": project}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// unarchiveProject handles POST /projects/:id/unarchive requests for unarchiving a project.
+func (h *Handler) unarchiveProject(w http.ResponseWriter, r *http.Request) {
+	id, err := h.readIDParam(r, "id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	principal := h.principal(r)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	project, err := h.ctrl.Unarchive(ctx, id, principal.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, controller.ErrNotFound):
+			h.notFoundResponse(w, r)
 		case errors.Is(err, controller.ErrEditConflict):
 			h.editConflictResponse(w, r)
+		case errors.Is(err, controller.ErrProjectNotArchived):
+			h.projectNotArchivedResponse(w, r)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
 		default:
-			h.serverErrorResponse(w, r, err)
+			h.controllerErrorResponse(w, r, err)
 		}
 		return
 	}
@@ -149,28 +348,71 @@ func (h *Handler) updateProject(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// deleteProject handles DELETE /projects requests for deleting a project.
+// deleteProject handles DELETE /projects requests for moving a project to the trash.
 func (h *Handler) deleteProject(w http.ResponseWriter, r *http.Request) {
 	id, err := h.readIDParam(r, "id")
 	if err != nil {
 		h.notFoundResponse(w, r)
 		return
 	}
+	ifMatch, err := h.readIfMatch(r)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	principal := h.principal(r)
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
-	err = h.ctrl.Delete(ctx, id)
+	expectedVersion, err := h.expectedVersion(ctx, id, ifMatch)
+	if err == nil {
+		err = h.ctrl.Delete(ctx, id, expectedVersion, principal.UserID)
+	}
 	if err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
 			return
 		case errors.Is(err, controller.ErrNotFound):
 			h.notFoundResponse(w, r)
+		case errors.Is(err, controller.ErrVersionMismatch):
+			h.preconditionFailedResponse(w, r)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
 		default:
-			h.serverErrorResponse(w, r, err)
+			h.controllerErrorResponse(w, r, err)
 		}
 		return
 	}
-	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "project successfully deleted"}, nil)
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "project successfully moved to trash"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// restoreProject handles POST /projects/:id/restore requests for restoring a project from the trash.
+func (h *Handler) restoreProject(w http.ResponseWriter, r *http.Request) {
+	id, err := h.readIDParam(r, "id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	principal := h.principal(r)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	project, err := h.ctrl.Restore(ctx, id, principal.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, controller.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
+		default:
+			h.controllerErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"project": project}, nil)
 	if err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
@@ -0,0 +1,174 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/emzola/venato/project/internal/controller"
+	"github.com/emzola/venato/project/pkg/model"
+)
+
+// addProjectMember handles POST /projects/:id/members requests for adding a user to a project.
+func (h *Handler) addProjectMember(w http.ResponseWriter, r *http.Request) {
+	id, err := h.readIDParam(r, "id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	var requestBody struct {
+		UserID int64      `json:"user_id"`
+		Role   model.Role `json:"role"`
+	}
+	err = h.decodeJSON(w, r, &requestBody)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	principal := h.principal(r)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	member, err := h.ctrl.AddMember(ctx, id, requestBody.UserID, requestBody.Role, principal.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, controller.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, controller.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, controller.ErrProjectArchived):
+			h.projectArchivedResponse(w, r)
+		case errors.Is(err, controller.ErrDuplicateMember):
+			h.duplicateMemberResponse(w, r)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
+		default:
+			h.controllerErrorResponse(w, r, err)
+		}
+		return
+	}
+	header := make(http.Header)
+	header.Set("Location", fmt.Sprintf("/projects/%d/members/%d", member.ProjectID, member.UserID))
+	err = h.encodeJSON(w, http.StatusCreated, envelop{"member": member}, header)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// getProjectMembers handles GET /projects/:id/members requests for listing the members of a project.
+func (h *Handler) getProjectMembers(w http.ResponseWriter, r *http.Request) {
+	id, err := h.readIDParam(r, "id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	members, err := h.ctrl.GetMembers(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, controller.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
+		default:
+			h.controllerErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"members": members}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateProjectMember handles PATCH /projects/:id/members/:user_id requests for changing the role of a project member.
+func (h *Handler) updateProjectMember(w http.ResponseWriter, r *http.Request) {
+	id, err := h.readIDParam(r, "id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userID, err := h.readIDParam(r, "user_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	var requestBody struct {
+		Role model.Role `json:"role"`
+	}
+	err = h.decodeJSON(w, r, &requestBody)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	member, err := h.ctrl.UpdateMember(ctx, id, userID, requestBody.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, controller.ErrFailedValidation):
+			h.failedValidationResponse(w, r, err)
+		case errors.Is(err, controller.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, controller.ErrProjectArchived):
+			h.projectArchivedResponse(w, r)
+		case errors.Is(err, controller.ErrLastOwner):
+			h.lastOwnerResponse(w, r)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
+		default:
+			h.controllerErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"member": member}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// removeProjectMember handles DELETE /projects/:id/members/:user_id requests for removing a user from a project.
+func (h *Handler) removeProjectMember(w http.ResponseWriter, r *http.Request) {
+	id, err := h.readIDParam(r, "id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	userID, err := h.readIDParam(r, "user_id")
+	if err != nil {
+		h.notFoundResponse(w, r)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	err = h.ctrl.RemoveMember(ctx, id, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case errors.Is(err, controller.ErrNotFound):
+			h.notFoundResponse(w, r)
+		case errors.Is(err, controller.ErrProjectArchived):
+			h.projectArchivedResponse(w, r)
+		case errors.Is(err, controller.ErrLastOwner):
+			h.lastOwnerResponse(w, r)
+		case errors.Is(err, controller.ErrPermissionDenied):
+			h.notPermittedResponse(w, r)
+		default:
+			h.controllerErrorResponse(w, r, err)
+		}
+		return
+	}
+	err = h.encodeJSON(w, http.StatusOK, envelop{"message": "member successfully removed"}, nil)
+	if err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/emzola/venato/project/internal/auth"
+	"github.com/emzola/venato/project/internal/authz"
+)
+
+// authenticate verifies the bearer token in the Authorization header
+// and stores the authenticated principal in the request context.
+func (h *Handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Authorization")
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			h.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
+		principal, err := h.verifier.Verify(token)
+		if err != nil {
+			h.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+	})
+}
+
+// requireAdmin restricts next to callers holding the service-wide admin role.
+func (h *Handler) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.principal(r).HasRole(authz.AdminRole) {
+			h.notPermittedResponse(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// principal returns the authenticated caller stored in the request context by authenticate.
+func (h *Handler) principal(r *http.Request) *auth.Principal {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		panic("missing principal value in request context")
+	}
+	return principal
+}
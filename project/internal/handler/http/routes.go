@@ -1,6 +1,7 @@
 package http
 
 import (
+	"expvar"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
@@ -15,5 +16,17 @@ func (h *Handler) Routes() http.Handler {
 	router.HandlerFunc(http.MethodGet, "/projects/:id", h.getProject)
 	router.HandlerFunc(http.MethodPatch, "/projects/:id", h.updateProject)
 	router.HandlerFunc(http.MethodDelete, "/projects/:id", h.deleteProject)
-	return router
+	router.HandlerFunc(http.MethodPost, "/projects/:id/restore", h.restoreProject)
+	router.HandlerFunc(http.MethodPost, "/projects/:id/transition", h.transitionProject)
+	router.HandlerFunc(http.MethodPost, "/projects/:id/archive", h.archiveProject)
+	router.HandlerFunc(http.MethodPost, "/projects/:id/unarchive", h.unarchiveProject)
+	router.HandlerFunc(http.MethodGet, "/projects/:id/history", h.getProjectHistory)
+	router.HandlerFunc(http.MethodGet, "/projects/:id/snapshot", h.getProjectSnapshot)
+	router.HandlerFunc(http.MethodPost, "/projects/:id/revert", h.revertProject)
+	router.HandlerFunc(http.MethodGet, "/projects/:id/members", h.getProjectMembers)
+	router.HandlerFunc(http.MethodPost, "/projects/:id/members", h.addProjectMember)
+	router.HandlerFunc(http.MethodPatch, "/projects/:id/members/:user_id", h.updateProjectMember)
+	router.HandlerFunc(http.MethodDelete, "/projects/:id/members/:user_id", h.removeProjectMember)
+	router.Handler(http.MethodGet, "/debug/vars", h.requireAdmin(expvar.Handler()))
+	return h.authenticate(router)
 }
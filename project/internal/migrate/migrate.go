@@ -0,0 +1,274 @@
+// Package migrate applies versioned SQL schema migrations to a PostgreSQL database.
+//
+// The current schema version is kept in a single-row schema_migrations table using the
+// same layout as the golang-migrate tool, so databases migrated by hand with it can be
+// taken over without changes.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// lockID identifies the session-level advisory lock that serializes migrations
+// across service replicas starting at the same time.
+const lockID int64 = 7214365913
+
+var (
+	// ErrDirty is returned when a previous migration failed part-way and the schema
+	// version must be fixed with Force before migrating again.
+	ErrDirty = errors.New("database schema is dirty")
+	// ErrUnknownVersion is returned when a version does not match any migration.
+	ErrUnknownVersion = errors.New("unknown migration version")
+)
+
+var filenameRX = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration defines a single schema change and its inverse.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes the schema version of a database and the known migrations.
+type Status struct {
+	Version    int64
+	Dirty      bool
+	Migrations []Migration
+}
+
+// Migrator applies migrations read from a file system to a database.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New creates a new migrator for the migration files at the root of source.
+func New(db *sql.DB, source fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		matches := filenameRX.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		data, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		} else if m.Name != matches[2] {
+			return nil, fmt.Errorf("duplicate migration version %d", version)
+		}
+		if matches[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up applies at most n pending migrations, or all of them if n is not positive,
+// and returns the number applied.
+func (m *Migrator) Up(ctx context.Context, n int) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := m.checkVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if migration.Version <= current {
+				continue
+			}
+			if n > 0 && applied == n {
+				break
+			}
+			if err := apply(ctx, conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts at most n applied migrations, or all of them if n is not positive,
+// and returns the number reverted.
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	reverted := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := m.checkVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version > current {
+				continue
+			}
+			if n > 0 && reverted == n {
+				break
+			}
+			var previous int64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := apply(ctx, conn, migration.Down, previous); err != nil {
+				return fmt.Errorf("revert migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Force records version as the current, clean schema version without running
+// any migration. A version of 0 marks the schema as having no migrations applied.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("%w %d", ErrUnknownVersion, version)
+	}
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if err := setVersion(ctx, tx, version); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// Status returns the current schema version and the known migrations.
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	status := Status{Migrations: m.migrations}
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		var err error
+		status.Version, status.Dirty, err = version(ctx, conn)
+		return err
+	})
+	return status, err
+}
+
+// known reports whether version matches a migration.
+func (m *Migrator) known(version int64) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// checkVersion returns the current schema version, failing if it is dirty or
+// does not match a known migration.
+func (m *Migrator) checkVersion(ctx context.Context, conn *sql.Conn) (int64, error) {
+	current, dirty, err := version(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w at version %d", ErrDirty, current)
+	}
+	if current != 0 && !m.known(current) {
+		return 0, fmt.Errorf("%w %d", ErrUnknownVersion, current)
+	}
+	return current, nil
+}
+
+// withLock runs fn on a dedicated connection while holding the migration advisory lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID)
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version bigint NOT NULL PRIMARY KEY,
+			dirty boolean NOT NULL
+		)`
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// version returns the schema version recorded in schema_migrations, which is 0 when
+// no migration has been applied.
+func version(ctx context.Context, conn *sql.Conn) (int64, bool, error) {
+	var version int64
+	var dirty bool
+	err := conn.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// apply runs body and records version in a single transaction, so a failed
+// migration leaves both the schema and its version untouched.
+func apply(ctx context.Context, conn *sql.Conn, body string, version int64) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return err
+	}
+	if err := setVersion(ctx, tx, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// setVersion replaces the recorded schema version with a clean version.
+func setVersion(ctx context.Context, tx *sql.Tx, version int64) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if version == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, version)
+	return err
+}
@@ -1,10 +1,45 @@
 package repository
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// ErrNotFound is returned when a requested record is not found.
 	ErrNotFound = errors.New("the requested resource could not be found")
 	// ErrEditConflict is returned when there ia an edit conflict due to a race condition.
 	ErrEditConflict = errors.New("unable to update the record due to an edit conflict, please try again")
+	// ErrDuplicateKey is returned when a project key is already taken.
+	ErrDuplicateKey = errors.New("a project with this key already exists")
+	// ErrDuplicateMember is returned when a user is already a member of a project.
+	ErrDuplicateMember = errors.New("the user is already a member of the project")
+	// ErrDuplicateIdempotencyKey is returned when an unexpired idempotency key is already recorded for a user.
+	ErrDuplicateIdempotencyKey = errors.New("the idempotency key has already been used")
+	// ErrUniqueViolation is returned when a record would duplicate a unique value.
+	ErrUniqueViolation = errors.New("the record duplicates a unique value")
+	// ErrCheckViolation is returned when a record fails a data integrity check.
+	ErrCheckViolation = errors.New("the record fails a data integrity check")
+	// ErrForeignKeyViolation is returned when a record refers to a missing record, or is referred to by another record.
+	ErrForeignKeyViolation = errors.New("the record violates a reference to another record")
+	// ErrQueryCanceled is returned when the database cancels a statement, for example because it timed out.
+	ErrQueryCanceled = errors.New("the database canceled the statement")
+	// ErrSerializationFailure is returned when a transaction conflicts with a concurrent one and may be retried.
+	ErrSerializationFailure = errors.New("the transaction conflicts with a concurrent transaction, please try again")
 )
+
+// ConstraintError records the database constraint a record violates.
+// It unwraps to ErrUniqueViolation, ErrCheckViolation or ErrForeignKeyViolation.
+type ConstraintError struct {
+	Constraint string
+	Err        error
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Constraint, e.Err)
+}
+
+// Unwrap allows ConstraintError to be matched against the kind of violation.
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/emzola/venato/project/pkg/model"
+)
+
+// CreateAuditEntry adds a new project audit entry.
+func (r *Repository) CreateAuditEntry(ctx context.Context, entry *model.AuditEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.lastAuditID++
+	entry.ID = r.lastAuditID
+	entry.CreatedOn = now()
+	stored := cloneAuditEntry(entry)
+	r.audit = append(r.audit, stored)
+	r.onRollback(ctx, func() {
+		for i, e := range r.audit {
+			if e == stored {
+				r.audit = append(r.audit[:i], r.audit[i+1:]...)
+				break
+			}
+		}
+	})
+	return nil
+}
+
+// GetAuditEntry retrieves the audit entry of the change which produced a version of a project.
+func (r *Repository) GetAuditEntry(ctx context.Context, projectID, version int64) (*model.AuditEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.RLock()
+	defer r.RUnlock()
+	for i := len(r.audit) - 1; i >= 0; i-- {
+		if entry := r.audit[i]; entry.ProjectID == projectID && entry.Version == version {
+			return cloneAuditEntry(entry), nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// GetAuditEntryAt retrieves the audit entry of the last change made to a project at or before the given time.
+func (r *Repository) GetAuditEntryAt(ctx context.Context, projectID int64, at time.Time) (*model.AuditEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.RLock()
+	defer r.RUnlock()
+	var last *model.AuditEntry
+	for _, entry := range r.audit {
+		if entry.ProjectID != projectID || entry.CreatedOn.After(at) {
+			continue
+		}
+		if last == nil || entry.Version >= last.Version {
+			last = entry
+		}
+	}
+	if last == nil {
+		return nil, repository.ErrNotFound
+	}
+	return cloneAuditEntry(last), nil
+}
+
+// GetHistory retrieves a paginated list of the audit entries of a project, ordered by version.
+func (r *Repository) GetHistory(ctx context.Context, projectID int64, filters model.Filters) ([]*model.AuditEntry, model.Metadata, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, model.Metadata{}, err
+	}
+	r.RLock()
+	defer r.RUnlock()
+	var matches []*model.AuditEntry
+	for _, entry := range r.audit {
+		if entry.ProjectID == projectID {
+			matches = append(matches, entry)
+		}
+	}
+	descending := filters.SortDirection() == "DESC"
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Version != b.Version {
+			return (a.Version < b.Version) != descending
+		}
+		return (a.ID < b.ID) != descending
+	})
+	entries := []*model.AuditEntry{}
+	if offset := filters.Offset(); offset < len(matches) {
+		end := offset + filters.Limit()
+		if end > len(matches) {
+			end = len(matches)
+		}
+		for _, entry := range matches[offset:end] {
+			entries = append(entries, cloneAuditEntry(entry))
+		}
+	}
+	// As with a windowed count, the total is only known when the page holds records.
+	totalRecords := 0
+	if len(entries) > 0 {
+		totalRecords = len(matches)
+	}
+	return entries, model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// cloneAuditEntry returns a copy of an audit entry that shares no memory with it.
+func cloneAuditEntry(entry *model.AuditEntry) *model.AuditEntry {
+	e := *entry
+	e.Changes = make(map[string]model.Change, len(entry.Changes))
+	for field, change := range entry.Changes {
+		e.Changes[field] = change
+	}
+	if entry.Snapshot != nil {
+		e.Snapshot = clone(entry.Snapshot)
+	}
+	return &e
+}
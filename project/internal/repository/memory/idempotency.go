@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/emzola/venato/project/pkg/model"
+)
+
+// idempotencyKeyID identifies an idempotency key, which is scoped to the user who sent it.
+type idempotencyKeyID struct {
+	userID int64
+	key    string
+}
+
+// CreateIdempotencyKey records an idempotency key, replacing an expired record of the
+// same key. It returns ErrDuplicateIdempotencyKey if the key is recorded and unexpired.
+func (r *Repository) CreateIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.Lock()
+	defer r.Unlock()
+	id := idempotencyKeyID{key.UserID, key.Key}
+	existing, ok := r.idempotencyKeys[id]
+	if ok && existing.ExpiresOn.After(time.Now()) {
+		return repository.ErrDuplicateIdempotencyKey
+	}
+	r.onRollback(ctx, func() {
+		if ok {
+			r.idempotencyKeys[id] = existing
+		} else {
+			delete(r.idempotencyKeys, id)
+		}
+	})
+	key.CreatedOn = now()
+	k := *key
+	k.ExpiresOn = key.ExpiresOn.Truncate(time.Second)
+	k.Response = append([]byte(nil), key.Response...)
+	r.idempotencyKeys[id] = &k
+	return nil
+}
+
+// GetIdempotencyKey retrieves the unexpired record of a user's idempotency key.
+func (r *Repository) GetIdempotencyKey(ctx context.Context, userID int64, key string) (*model.IdempotencyKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.RLock()
+	defer r.RUnlock()
+	record, ok := r.idempotencyKeys[idempotencyKeyID{userID, key}]
+	if !ok || !record.ExpiresOn.After(time.Now()) {
+		return nil, repository.ErrNotFound
+	}
+	k := *record
+	k.Response = append([]byte(nil), record.Response...)
+	return &k, nil
+}
+
+// PurgeIdempotencyKeys permanently removes the idempotency keys which expired
+// before the given time, and returns the number of keys removed.
+func (r *Repository) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.Lock()
+	defer r.Unlock()
+	var purged int64
+	for id, record := range r.idempotencyKeys {
+		if record.ExpiresOn.Before(before) {
+			id, record := id, record
+			delete(r.idempotencyKeys, id)
+			r.onRollback(ctx, func() { r.idempotencyKeys[id] = record })
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/emzola/venato/project/pkg/model"
+	"github.com/emzola/venato/project/pkg/validator"
+)
+
+// AddMember adds a new project member record.
+func (r *Repository) AddMember(ctx context.Context, member *model.Member) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.projects[member.ProjectID]; !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkMember(member); err != nil {
+		return err
+	}
+	if _, ok := r.members[member.ProjectID][member.UserID]; ok {
+		return repository.ErrDuplicateMember
+	}
+	if r.members[member.ProjectID] == nil {
+		r.members[member.ProjectID] = map[int64]*model.Member{}
+	}
+	member.AddedOn = now()
+	stored := *member
+	r.members[member.ProjectID][member.UserID] = &stored
+	r.onRollback(ctx, func() { delete(r.members[stored.ProjectID], stored.UserID) })
+	return nil
+}
+
+// GetMember retrieves a project member record by its project and user ids.
+func (r *Repository) GetMember(ctx context.Context, projectID, userID int64) (*model.Member, error) {
+	if projectID < 1 || userID < 1 {
+		return nil, repository.ErrNotFound
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.RLock()
+	defer r.RUnlock()
+	member, ok := r.members[projectID][userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	m := *member
+	return &m, nil
+}
+
+// GetMembers retrieves the member records of a project.
+func (r *Repository) GetMembers(ctx context.Context, projectID int64) ([]*model.Member, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.RLock()
+	defer r.RUnlock()
+	members := []*model.Member{}
+	for _, member := range r.members[projectID] {
+		m := *member
+		members = append(members, &m)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if !members[i].AddedOn.Equal(members[j].AddedOn) {
+			return members[i].AddedOn.Before(members[j].AddedOn)
+		}
+		return members[i].UserID < members[j].UserID
+	})
+	return members, nil
+}
+
+// UpdateMember updates the role of a project member record.
+func (r *Repository) UpdateMember(ctx context.Context, member *model.Member) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.Lock()
+	defer r.Unlock()
+	stored, ok := r.members[member.ProjectID][member.UserID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if err := checkMember(member); err != nil {
+		return err
+	}
+	role := stored.Role
+	r.onRollback(ctx, func() { stored.Role = role })
+	stored.Role = member.Role
+	return nil
+}
+
+// RemoveMember removes a project member record by its project and user ids.
+func (r *Repository) RemoveMember(ctx context.Context, projectID, userID int64) error {
+	if projectID < 1 || userID < 1 {
+		return repository.ErrNotFound
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.Lock()
+	defer r.Unlock()
+	stored, ok := r.members[projectID][userID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.members[projectID], userID)
+	r.onRollback(ctx, func() { r.members[projectID][userID] = stored })
+	return nil
+}
+
+// CountOwners returns the number of owners of a project.
+func (r *Repository) CountOwners(ctx context.Context, projectID int64) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.RLock()
+	defer r.RUnlock()
+	owners := 0
+	for _, member := range r.members[projectID] {
+		if member.Role == model.RoleOwner {
+			owners++
+		}
+	}
+	return owners, nil
+}
+
+// checkMember enforces the check constraints of the project member table.
+func checkMember(member *model.Member) error {
+	if !validator.In(string(member.Role), model.Roles...) {
+		return &repository.ConstraintError{Constraint: "project_member_role_check", Err: repository.ErrCheckViolation}
+	}
+	return nil
+}
@@ -0,0 +1,537 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/emzola/venato/project/pkg/model"
+	"github.com/emzola/venato/project/pkg/validator"
+)
+
+// Repository defines a memory project repository. It reproduces the behaviour of
+// the PostgreSQL repository and is meant for tests and local development.
+type Repository struct {
+	sync.RWMutex
+	txMu     sync.Mutex // serializes transactions started by RunInTx.
+	lastID   int64
+	projects map[int64]*model.Project
+	members  map[int64]map[int64]*model.Member
+
+	idempotencyKeys map[idempotencyKeyID]*model.IdempotencyKey
+	audit           []*model.AuditEntry // holds the audit entries in the order they were added.
+	lastAuditID     int64
+}
+
+// New creates a new memory repository.
+func New() *Repository {
+	return &Repository{
+		projects: map[int64]*model.Project{},
+		members:  map[int64]map[int64]*model.Member{},
+
+		idempotencyKeys: map[idempotencyKeyID]*model.IdempotencyKey{},
+	}
+}
+
+// now returns the current time at the one second precision of the database timestamps.
+func now() time.Time {
+	return time.Now().Truncate(time.Second)
+}
+
+// clone returns a copy of a project record that shares no memory with it.
+func clone(project *model.Project) *model.Project {
+	p := *project
+	if project.ActualEndDate != nil {
+		actualEndDate := *project.ActualEndDate
+		p.ActualEndDate = &actualEndDate
+	}
+	if project.DeletedOn != nil {
+		deletedOn := *project.DeletedOn
+		p.DeletedOn = &deletedOn
+	}
+	if project.ArchivedOn != nil {
+		archivedOn := *project.ArchivedOn
+		p.ArchivedOn = &archivedOn
+	}
+	return &p
+}
+
+// keepForRollback records the current state of a stored project, so that a
+// rollback of the transaction in ctx restores it. The lock must be held for writing.
+func (r *Repository) keepForRollback(ctx context.Context, project *model.Project) {
+	previous := clone(project)
+	r.onRollback(ctx, func() { r.projects[previous.ID] = previous })
+}
+
+// checkProject enforces the check constraints of the project table.
+func checkProject(project *model.Project) error {
+	switch {
+	case project.TargetEndDate.Before(project.StartDate):
+		return &repository.ConstraintError{Constraint: "project_target_end_date_check", Err: repository.ErrCheckViolation}
+	case project.ActualEndDate != nil && project.ActualEndDate.Before(project.StartDate):
+		return &repository.ConstraintError{Constraint: "project_actual_end_date_check", Err: repository.ErrCheckViolation}
+	case !validator.In(string(project.Status), model.ProjectStatuses...):
+		return &repository.ConstraintError{Constraint: "project_status_check", Err: repository.ErrCheckViolation}
+	}
+	return nil
+}
+
+// Create adds a new project record.
+func (r *Repository) Create(ctx context.Context, project *model.Project) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.Lock()
+	defer r.Unlock()
+	if err := checkProject(project); err != nil {
+		return err
+	}
+	for _, p := range r.projects {
+		if p.Key == project.Key {
+			return repository.ErrDuplicateKey
+		}
+	}
+	r.lastID++
+	created := now()
+	stored := &model.Project{
+		ID:            r.lastID,
+		Name:          project.Name,
+		Key:           project.Key,
+		Description:   project.Description,
+		Status:        project.Status,
+		StartDate:     project.StartDate,
+		TargetEndDate: project.TargetEndDate,
+		CreatedOn:     created,
+		CreatedBy:     project.CreatedBy,
+		ModifiedOn:    created,
+		ModifiedBy:    project.ModifiedBy,
+		Version:       1,
+	}
+	r.projects[stored.ID] = stored
+	r.onRollback(ctx, func() { delete(r.projects, stored.ID) })
+	project.ID, project.CreatedOn, project.Version = stored.ID, stored.CreatedOn, stored.Version
+	return nil
+}
+
+// Get retrieves a project record by its id. Projects in the trash are not returned.
+func (r *Repository) Get(ctx context.Context, id int64) (*model.Project, error) {
+	if id < 1 {
+		return nil, repository.ErrNotFound
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.RLock()
+	defer r.RUnlock()
+	project, ok := r.projects[id]
+	if !ok || project.DeletedOn != nil {
+		return nil, repository.ErrNotFound
+	}
+	return clone(project), nil
+}
+
+// GetTrashed retrieves a project record in the trash by its id.
+func (r *Repository) GetTrashed(ctx context.Context, id int64) (*model.Project, error) {
+	if id < 1 {
+		return nil, repository.ErrNotFound
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.RLock()
+	defer r.RUnlock()
+	project, ok := r.projects[id]
+	if !ok || project.DeletedOn == nil {
+		return nil, repository.ErrNotFound
+	}
+	return clone(project), nil
+}
+
+// GetByKey retrieves a project record by its key. Projects in the trash are not returned.
+func (r *Repository) GetByKey(ctx context.Context, key string) (*model.Project, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.RLock()
+	defer r.RUnlock()
+	for _, project := range r.projects {
+		if project.Key == key && project.DeletedOn == nil {
+			return clone(project), nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// KeyExists reports whether a project key is taken, including by projects in the trash.
+func (r *Repository) KeyExists(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	r.RLock()
+	defer r.RUnlock()
+	for _, project := range r.projects {
+		if project.Key == key {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetAll retrieves a paginated list of project records matching the given query.
+// Sorting by relevance ranks records against the full-text search term. Projects
+// in the trash or the archive are only listed, exclusively, when the query asks for them.
+func (r *Repository) GetAll(ctx context.Context, q model.ProjectQuery, filters model.Filters) ([]*model.Project, model.Metadata, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, model.Metadata{}, err
+	}
+	if filters.CursorMode {
+		return r.getAllByCursor(q, filters)
+	}
+	matches, ranks := r.list(q)
+	sortProjects(matches, ranks, filters.SortColumn(), filters.SortDirection() == "DESC")
+	projects := []*model.Project{}
+	if offset := filters.Offset(); offset < len(matches) {
+		end := offset + filters.Limit()
+		if end > len(matches) {
+			end = len(matches)
+		}
+		projects = append(projects, matches[offset:end]...)
+	}
+	// As with a windowed count, the total is only known when the page holds records.
+	totalRecords := 0
+	if len(projects) > 0 {
+		totalRecords = len(matches)
+	}
+	return projects, model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// getAllByCursor retrieves a page of project records positioned after the record
+// encoded in the filters cursor, or before it when the cursor points backward.
+func (r *Repository) getAllByCursor(q model.ProjectQuery, filters model.Filters) ([]*model.Project, model.Metadata, error) {
+	var cursor model.Cursor
+	if filters.Cursor != "" {
+		c, err := model.DecodeCursor(filters.Cursor)
+		if err != nil {
+			return nil, model.Metadata{}, err
+		}
+		cursor = c
+	}
+	sortColumn := filters.SortColumn()
+	// A backward page is read in reverse order and flipped back afterwards.
+	desc := (filters.SortDirection() == "DESC") != cursor.Backward
+	matches, ranks := r.list(q)
+	sortProjects(matches, ranks, sortColumn, desc)
+	projects := []*model.Project{}
+	for _, project := range matches {
+		if filters.Cursor != "" {
+			cmp := compareToCursor(project, sortColumn, cursor)
+			if (desc && cmp >= 0) || (!desc && cmp <= 0) {
+				continue
+			}
+		}
+		projects = append(projects, project)
+		// One extra record is kept to find out whether another page follows.
+		if len(projects) > filters.Limit() {
+			break
+		}
+	}
+	hasMore := len(projects) > filters.Limit()
+	if hasMore {
+		projects = projects[:filters.Limit()]
+	}
+	hasNext, hasPrev := hasMore, filters.Cursor != ""
+	if cursor.Backward {
+		for i, j := 0, len(projects)-1; i < j; i, j = i+1, j-1 {
+			projects[i], projects[j] = projects[j], projects[i]
+		}
+		hasNext, hasPrev = true, hasMore
+	}
+	metadata := model.Metadata{PageSize: filters.PageSize}
+	if len(projects) > 0 {
+		first, last := projects[0], projects[len(projects)-1]
+		if hasNext {
+			metadata.NextCursor = model.EncodeCursor(model.Cursor{Sort: filters.Sort, Value: sortValue(last, sortColumn), ID: last.ID})
+		}
+		if hasPrev {
+			metadata.PrevCursor = model.EncodeCursor(model.Cursor{Sort: filters.Sort, Value: sortValue(first, sortColumn), ID: first.ID, Backward: true})
+		}
+	}
+	return projects, metadata, nil
+}
+
+// list returns copies of the project records matching the query, together with
+// their full-text search rank keyed by id.
+func (r *Repository) list(q model.ProjectQuery) ([]*model.Project, map[int64]float64) {
+	r.RLock()
+	defer r.RUnlock()
+	terms := words(q.Search)
+	projects := []*model.Project{}
+	ranks := map[int64]float64{}
+	for _, project := range r.projects {
+		if q.Name != "" && !strings.EqualFold(project.Name, q.Name) {
+			continue
+		}
+		if (project.DeletedOn != nil) != q.Trashed || (project.ArchivedOn != nil) != q.Archived {
+			continue
+		}
+		if q.MemberID != 0 {
+			if _, ok := r.members[project.ID][q.MemberID]; !ok {
+				continue
+			}
+		}
+		rank, ok := rank(project, terms)
+		if !ok {
+			continue
+		}
+		ranks[project.ID] = rank
+		projects = append(projects, clone(project))
+	}
+	return projects, ranks
+}
+
+// rank approximates PostgreSQL full-text matching: every search word must appear in
+// the project name or description, and words found in the name weigh more.
+func rank(project *model.Project, terms []string) (float64, bool) {
+	if len(terms) == 0 {
+		return 0, true
+	}
+	name, description := wordSet(project.Name), wordSet(project.Description)
+	var rank float64
+	for _, term := range terms {
+		inName, inDescription := name[term], description[term]
+		if !inName && !inDescription {
+			return 0, false
+		}
+		if inName {
+			rank += 1.0
+		}
+		if inDescription {
+			rank += 0.4
+		}
+	}
+	return rank, true
+}
+
+// words splits text into lower-cased, crudely stemmed words.
+func words(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, field := range fields {
+		for _, suffix := range []string{"ing", "es", "ed", "s"} {
+			if len(field) > len(suffix)+2 && strings.HasSuffix(field, suffix) {
+				fields[i] = strings.TrimSuffix(field, suffix)
+				break
+			}
+		}
+	}
+	return fields
+}
+
+func wordSet(text string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range words(text) {
+		set[word] = true
+	}
+	return set
+}
+
+// sortProjects orders projects by the sort column with id as a tie-breaker.
+func sortProjects(projects []*model.Project, ranks map[int64]float64, column string, desc bool) {
+	sort.Slice(projects, func(i, j int) bool {
+		a, b := projects[i], projects[j]
+		cmp := 0
+		switch column {
+		case "name":
+			cmp = strings.Compare(a.Name, b.Name)
+		case "created_on":
+			cmp = a.CreatedOn.Compare(b.CreatedOn)
+		case "modified_on":
+			cmp = a.ModifiedOn.Compare(b.ModifiedOn)
+		case "relevance":
+			cmp = compareFloat(ranks[a.ID], ranks[b.ID])
+		}
+		if cmp == 0 {
+			cmp = compareInt(a.ID, b.ID)
+		}
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+}
+
+// compareToCursor compares the (column, id) position of a project with the position
+// encoded in a cursor.
+func compareToCursor(project *model.Project, column string, cursor model.Cursor) int {
+	cmp := 0
+	switch column {
+	case "name":
+		cmp = strings.Compare(project.Name, cursor.Value)
+	case "created_on", "modified_on":
+		value, _ := time.Parse(time.RFC3339Nano, cursor.Value)
+		if column == "created_on" {
+			cmp = project.CreatedOn.Compare(value)
+		} else {
+			cmp = project.ModifiedOn.Compare(value)
+		}
+	default:
+		value, _ := strconv.ParseInt(cursor.Value, 10, 64)
+		cmp = compareInt(project.ID, value)
+	}
+	if cmp == 0 {
+		cmp = compareInt(project.ID, cursor.ID)
+	}
+	return cmp
+}
+
+// sortValue returns the value of a project's sort column as encoded in a cursor.
+func sortValue(project *model.Project, column string) string {
+	switch column {
+	case "name":
+		return project.Name
+	case "created_on":
+		return project.CreatedOn.Format(time.RFC3339Nano)
+	case "modified_on":
+		return project.ModifiedOn.Format(time.RFC3339Nano)
+	default:
+		return strconv.FormatInt(project.ID, 10)
+	}
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Update updates a project record.
+func (r *Repository) Update(ctx context.Context, project *model.Project) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.Lock()
+	defer r.Unlock()
+	stored, ok := r.projects[project.ID]
+	if !ok || stored.Version != project.Version || stored.DeletedOn != nil {
+		return repository.ErrEditConflict
+	}
+	if err := checkProject(project); err != nil {
+		return err
+	}
+	r.keepForRollback(ctx, stored)
+	stored.Name = project.Name
+	stored.Description = project.Description
+	stored.Status = project.Status
+	stored.StartDate = project.StartDate
+	stored.TargetEndDate = project.TargetEndDate
+	stored.ActualEndDate = nil
+	if project.ActualEndDate != nil {
+		actualEndDate := *project.ActualEndDate
+		stored.ActualEndDate = &actualEndDate
+	}
+	stored.ArchivedOn = nil
+	stored.ArchivedBy = 0
+	if project.ArchivedOn != nil {
+		archivedOn := *project.ArchivedOn
+		stored.ArchivedOn = &archivedOn
+		stored.ArchivedBy = project.ArchivedBy
+	}
+	stored.ModifiedOn = now()
+	stored.ModifiedBy = project.ModifiedBy
+	stored.Version++
+	project.ModifiedOn, project.Version = stored.ModifiedOn, stored.Version
+	return nil
+}
+
+// Delete moves a project record to the trash by its id.
+func (r *Repository) Delete(ctx context.Context, id int64, deletedBy int64) error {
+	if id < 1 {
+		return repository.ErrNotFound
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.Lock()
+	defer r.Unlock()
+	project, ok := r.projects[id]
+	if !ok || project.DeletedOn != nil {
+		return repository.ErrNotFound
+	}
+	r.keepForRollback(ctx, project)
+	deletedOn := now()
+	project.DeletedOn = &deletedOn
+	project.DeletedBy = deletedBy
+	project.ModifiedOn = deletedOn
+	project.ModifiedBy = deletedBy
+	project.Version++
+	return nil
+}
+
+// Restore moves a project record out of the trash by its id.
+func (r *Repository) Restore(ctx context.Context, id int64, restoredBy int64) (*model.Project, error) {
+	if id < 1 {
+		return nil, repository.ErrNotFound
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.Lock()
+	defer r.Unlock()
+	project, ok := r.projects[id]
+	if !ok || project.DeletedOn == nil {
+		return nil, repository.ErrNotFound
+	}
+	r.keepForRollback(ctx, project)
+	project.DeletedOn = nil
+	project.DeletedBy = 0
+	project.ModifiedOn = now()
+	project.ModifiedBy = restoredBy
+	project.Version++
+	return clone(project), nil
+}
+
+// Purge permanently removes project records that were moved to the trash
+// before the given time, and returns the number of records removed.
+func (r *Repository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.Lock()
+	defer r.Unlock()
+	var purged int64
+	for id, project := range r.projects {
+		if project.DeletedOn != nil && project.DeletedOn.Before(before) {
+			project, members := project, r.members[id]
+			delete(r.projects, id)
+			delete(r.members, id)
+			r.onRollback(ctx, func() {
+				r.projects[project.ID] = project
+				if members != nil {
+					r.members[project.ID] = members
+				}
+			})
+			purged++
+		}
+	}
+	return purged, nil
+}
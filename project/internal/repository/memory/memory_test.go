@@ -0,0 +1,13 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/emzola/venato/project/internal/repository/repotest"
+)
+
+func TestRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repository {
+		return New()
+	})
+}
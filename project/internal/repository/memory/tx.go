@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+)
+
+// txKey is the context key under which RunInTx stores the active transaction.
+type txKey struct{}
+
+// tx records how to undo each change made in a transaction, in the order the changes were made.
+type tx struct {
+	undo []func()
+}
+
+// RunInTx calls fn with a context carrying a new transaction. The changes made by fn
+// are kept if it returns nil and undone if it returns an error or panics. When ctx
+// is already inside a transaction, fn joins it instead of starting a new one.
+//
+// Transactions are serialized with each other but not isolated from calls made outside
+// of a transaction, which may observe uncommitted changes. A rollback only undoes the
+// changes made in the transaction. As with database sequences, the ids taken by records
+// created in a transaction are not given out again after a rollback.
+func (r *Repository) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*tx); ok {
+		return fn(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+	t := &tx{}
+	defer func() {
+		if p := recover(); p != nil {
+			r.rollback(t)
+			panic(p)
+		}
+	}()
+	if err := fn(context.WithValue(ctx, txKey{}, t)); err != nil {
+		r.rollback(t)
+		return err
+	}
+	return nil
+}
+
+// onRollback records how to undo a change made with ctx, if ctx is inside a
+// transaction. It must be called with the repository write lock held.
+func (r *Repository) onRollback(ctx context.Context, undo func()) {
+	if t, ok := ctx.Value(txKey{}).(*tx); ok {
+		t.undo = append(t.undo, undo)
+	}
+}
+
+// rollback undoes the changes made in a transaction, the most recent first.
+func (r *Repository) rollback(t *tx) {
+	r.Lock()
+	defer r.Unlock()
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		t.undo[i]()
+	}
+}
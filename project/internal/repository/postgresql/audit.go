@@ -0,0 +1,142 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/emzola/venato/project/pkg/model"
+)
+
+// auditColumns lists the project_audit columns read by scanAuditEntry, in order.
+const auditColumns = `id, project_id, version, action, actor, created_on, changes, snapshot`
+
+// scanAuditEntry scans the auditColumns of a row into an audit entry. Any extra
+// destinations are scanned first, from the columns selected before auditColumns.
+func scanAuditEntry(row rowScanner, entry *model.AuditEntry, extra ...interface{}) error {
+	var changes, snapshot []byte
+	dest := append(extra,
+		&entry.ID,
+		&entry.ProjectID,
+		&entry.Version,
+		&entry.Action,
+		&entry.Actor,
+		&entry.CreatedOn,
+		&changes,
+		&snapshot,
+	)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+		return err
+	}
+	if snapshot != nil {
+		entry.Snapshot = &model.Project{}
+		return json.Unmarshal(snapshot, entry.Snapshot)
+	}
+	return nil
+}
+
+// CreateAuditEntry adds a new project audit entry.
+func (r *Repository) CreateAuditEntry(ctx context.Context, entry *model.AuditEntry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+	// JSON is passed as text, which lib/pq would otherwise send as bytea, and an
+	// absent snapshot as a nil interface so that it is stored as NULL.
+	var snapshot interface{}
+	if entry.Snapshot != nil {
+		js, err := json.Marshal(entry.Snapshot)
+		if err != nil {
+			return err
+		}
+		snapshot = string(js)
+	}
+	query := `
+		INSERT INTO project_audit (project_id, version, action, actor, changes, snapshot)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_on`
+	args := []interface{}{entry.ProjectID, entry.Version, entry.Action, entry.Actor, string(changes), snapshot}
+	err = r.executor(ctx).QueryRowContext(ctx, query, args...).Scan(&entry.ID, &entry.CreatedOn)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	return nil
+}
+
+// GetAuditEntry retrieves the audit entry of the change which produced a version of a project.
+func (r *Repository) GetAuditEntry(ctx context.Context, projectID, version int64) (*model.AuditEntry, error) {
+	query := `
+		SELECT ` + auditColumns + `
+		FROM project_audit
+		WHERE project_id = $1 AND version = $2
+		ORDER BY id DESC
+		LIMIT 1`
+	var entry model.AuditEntry
+	err := scanAuditEntry(r.executor(ctx).QueryRowContext(ctx, query, projectID, version), &entry)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrNotFound
+		default:
+			return nil, translateError(ctx, err)
+		}
+	}
+	return &entry, nil
+}
+
+// GetAuditEntryAt retrieves the audit entry of the last change made to a project at or before the given time.
+func (r *Repository) GetAuditEntryAt(ctx context.Context, projectID int64, at time.Time) (*model.AuditEntry, error) {
+	query := `
+		SELECT ` + auditColumns + `
+		FROM project_audit
+		WHERE project_id = $1 AND created_on <= $2
+		ORDER BY version DESC, id DESC
+		LIMIT 1`
+	var entry model.AuditEntry
+	err := scanAuditEntry(r.executor(ctx).QueryRowContext(ctx, query, projectID, at), &entry)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrNotFound
+		default:
+			return nil, translateError(ctx, err)
+		}
+	}
+	return &entry, nil
+}
+
+// GetHistory retrieves a paginated list of the audit entries of a project, ordered by version.
+func (r *Repository) GetHistory(ctx context.Context, projectID int64, filters model.Filters) ([]*model.AuditEntry, model.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), %s
+		FROM project_audit
+		WHERE project_id = $1
+		ORDER BY version %s, id %s
+		LIMIT $2 OFFSET $3`, auditColumns, filters.SortDirection(), filters.SortDirection())
+	rows, err := r.executor(ctx).QueryContext(ctx, query, projectID, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, model.Metadata{}, translateError(ctx, err)
+	}
+	defer rows.Close()
+	totalRecords := 0
+	entries := []*model.AuditEntry{}
+	for rows.Next() {
+		var entry model.AuditEntry
+		if err := scanAuditEntry(rows, &entry, &totalRecords); err != nil {
+			return nil, model.Metadata{}, translateError(ctx, err)
+		}
+		entries = append(entries, &entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, model.Metadata{}, translateError(ctx, err)
+	}
+	metadata := model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return entries, metadata, nil
+}
@@ -0,0 +1,46 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/lib/pq"
+)
+
+// translateError converts a database error into the matching repository error.
+// Violations of constraints with a dedicated meaning map to their own errors.
+// Errors that are not reported by the database server are returned unchanged.
+func translateError(ctx context.Context, err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code.Name() {
+	case "query_canceled":
+		// Statements canceled on behalf of the caller report the context error.
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("%v: %w", err, ctxErr)
+		}
+		return fmt.Errorf("%v: %w", err, repository.ErrQueryCanceled)
+	case "unique_violation":
+		switch pqErr.Constraint {
+		case "project_key_idx":
+			return repository.ErrDuplicateKey
+		case "project_member_pkey":
+			return repository.ErrDuplicateMember
+		}
+		return &repository.ConstraintError{Constraint: pqErr.Constraint, Err: repository.ErrUniqueViolation}
+	case "check_violation":
+		return &repository.ConstraintError{Constraint: pqErr.Constraint, Err: repository.ErrCheckViolation}
+	case "foreign_key_violation":
+		if pqErr.Constraint == "project_member_project_id_fkey" {
+			return repository.ErrNotFound
+		}
+		return &repository.ConstraintError{Constraint: pqErr.Constraint, Err: repository.ErrForeignKeyViolation}
+	case "serialization_failure", "deadlock_detected":
+		return fmt.Errorf("%v: %w", err, repository.ErrSerializationFailure)
+	}
+	return err
+}
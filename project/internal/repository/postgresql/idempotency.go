@@ -0,0 +1,75 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/emzola/venato/project/pkg/model"
+)
+
+// CreateIdempotencyKey records an idempotency key, replacing an expired record of the
+// same key. It returns ErrDuplicateIdempotencyKey if the key is recorded and unexpired.
+func (r *Repository) CreateIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) error {
+	query := `
+		INSERT INTO idempotency_key (user_id, key, request_hash, response, expires_on)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, response = EXCLUDED.response,
+			created_on = CURRENT_TIMESTAMP(0), expires_on = EXCLUDED.expires_on
+		WHERE idempotency_key.expires_on <= CURRENT_TIMESTAMP
+		RETURNING created_on`
+	// The response is passed as text, which lib/pq would otherwise send as bytea.
+	args := []interface{}{key.UserID, key.Key, key.RequestHash, string(key.Response), key.ExpiresOn}
+	err := r.executor(ctx).QueryRowContext(ctx, query, args...).Scan(&key.CreatedOn)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return repository.ErrDuplicateIdempotencyKey
+		default:
+			return translateError(ctx, err)
+		}
+	}
+	return nil
+}
+
+// GetIdempotencyKey retrieves the unexpired record of a user's idempotency key.
+func (r *Repository) GetIdempotencyKey(ctx context.Context, userID int64, key string) (*model.IdempotencyKey, error) {
+	query := `
+		SELECT user_id, key, request_hash, response, created_on, expires_on
+		FROM idempotency_key
+		WHERE user_id = $1 AND key = $2 AND expires_on > CURRENT_TIMESTAMP`
+	var record model.IdempotencyKey
+	err := r.executor(ctx).QueryRowContext(ctx, query, userID, key).Scan(
+		&record.UserID,
+		&record.Key,
+		&record.RequestHash,
+		&record.Response,
+		&record.CreatedOn,
+		&record.ExpiresOn,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrNotFound
+		default:
+			return nil, translateError(ctx, err)
+		}
+	}
+	return &record, nil
+}
+
+// PurgeIdempotencyKeys permanently removes the idempotency keys which expired
+// before the given time, and returns the number of keys removed.
+func (r *Repository) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM idempotency_key
+		WHERE expires_on < $1`
+	result, err := r.executor(ctx).ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,147 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/emzola/venato/project/pkg/model"
+)
+
+// AddMember adds a new project member record.
+func (r *Repository) AddMember(ctx context.Context, member *model.Member) error {
+	query := `
+		INSERT INTO project_member (project_id, user_id, role, added_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING added_on`
+	args := []interface{}{member.ProjectID, member.UserID, member.Role, member.AddedBy}
+	err := r.executor(ctx).QueryRowContext(ctx, query, args...).Scan(&member.AddedOn)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	return nil
+}
+
+// GetMember retrieves a project member record by its project and user ids.
+func (r *Repository) GetMember(ctx context.Context, projectID, userID int64) (*model.Member, error) {
+	if projectID < 1 || userID < 1 {
+		return nil, repository.ErrNotFound
+	}
+	query := `
+		SELECT project_id, user_id, role, added_on, added_by
+		FROM project_member
+		WHERE project_id = $1 AND user_id = $2`
+	var member model.Member
+	err := r.executor(ctx).QueryRowContext(ctx, query, projectID, userID).Scan(
+		&member.ProjectID,
+		&member.UserID,
+		&member.Role,
+		&member.AddedOn,
+		&member.AddedBy,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrNotFound
+		default:
+			return nil, translateError(ctx, err)
+		}
+	}
+	return &member, nil
+}
+
+// GetMembers retrieves the member records of a project.
+func (r *Repository) GetMembers(ctx context.Context, projectID int64) ([]*model.Member, error) {
+	query := `
+		SELECT project_id, user_id, role, added_on, added_by
+		FROM project_member
+		WHERE project_id = $1
+		ORDER BY added_on ASC, user_id ASC`
+	rows, err := r.executor(ctx).QueryContext(ctx, query, projectID)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	defer rows.Close()
+	members := []*model.Member{}
+	for rows.Next() {
+		var member model.Member
+		err := rows.Scan(
+			&member.ProjectID,
+			&member.UserID,
+			&member.Role,
+			&member.AddedOn,
+			&member.AddedBy,
+		)
+		if err != nil {
+			return nil, translateError(ctx, err)
+		}
+		members = append(members, &member)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return members, nil
+}
+
+// UpdateMember updates the role of a project member record.
+func (r *Repository) UpdateMember(ctx context.Context, member *model.Member) error {
+	query := `
+		UPDATE project_member
+		SET role = $1
+		WHERE project_id = $2 AND user_id = $3`
+	result, err := r.executor(ctx).ExecContext(ctx, query, member.Role, member.ProjectID, member.UserID)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// RemoveMember removes a project member record by its project and user ids.
+func (r *Repository) RemoveMember(ctx context.Context, projectID, userID int64) error {
+	if projectID < 1 || userID < 1 {
+		return repository.ErrNotFound
+	}
+	query := `
+		DELETE FROM project_member
+		WHERE project_id = $1 AND user_id = $2`
+	result, err := r.executor(ctx).ExecContext(ctx, query, projectID, userID)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// CountOwners returns the number of owners of a project. The owner records are
+// locked until the end of the transaction, if any, so that a concurrent transaction
+// changing the owners waits for it and then counts the owners that remain.
+func (r *Repository) CountOwners(ctx context.Context, projectID int64) (int, error) {
+	query := `
+		SELECT count(*)
+		FROM (
+			SELECT user_id
+			FROM project_member
+			WHERE project_id = $1 AND role = 'owner'
+			FOR UPDATE
+		) AS owner`
+	var owners int
+	err := r.executor(ctx).QueryRowContext(ctx, query, projectID).Scan(&owners)
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+	return owners, nil
+}
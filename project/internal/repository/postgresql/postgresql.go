@@ -5,7 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"os"
+	"strconv"
+	"time"
 
 	"github.com/emzola/venato/project/internal/repository"
 	"github.com/emzola/venato/project/pkg/model"
@@ -17,39 +18,90 @@ type Repository struct {
 	db *sql.DB
 }
 
-// New creates a new PostgreSQL-based repository.
-func New() (*Repository, error) {
-	db, err := sql.Open("postgres", os.Getenv("DATABASE_URL"))
+// Config holds the connection settings of a PostgreSQL-based repository.
+type Config struct {
+	URL             string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// ConnectTimeout bounds how long New keeps retrying the initial connectivity check.
+	ConnectTimeout time.Duration
+}
+
+// Open creates a database connection pool and verifies that the database is
+// reachable, retrying with exponential backoff until cfg.ConnectTimeout elapses.
+func Open(ctx context.Context, cfg Config) (*sql.DB, error) {
+	db, err := sql.Open("postgres", cfg.URL)
 	if err != nil {
 		return nil, err
 	}
-	return &Repository{db}, nil
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	if err := ping(ctx, db, cfg.ConnectTimeout); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
 }
 
-// Create adds a new project record.
-func (r *Repository) Create(ctx context.Context, project *model.Project) error {
-	query := `
-			INSERT INTO project (name, description, start_date, target_end_date, created_by, modified_by)
-			VALUES ($1, $2, $3, $4, $5, $6)
-		  	RETURNING id, created_on, version`
-	args := []interface{}{project.Name, project.Description, project.StartDate, project.TargetEndDate, project.CreatedBy, project.ModifiedBy}
-	return r.db.QueryRowContext(ctx, query, args...).Scan(&project.ID, &project.CreatedOn, &project.Version)
+// New creates a new PostgreSQL-based repository on top of a pool created by Open.
+func New(db *sql.DB) *Repository {
+	return &Repository{db}
 }
 
-// Get retrieves a project record by its id.
-func (r *Repository) Get(ctx context.Context, id int64) (*model.Project, error) {
-	if id < 1 {
-		return nil, repository.ErrNotFound
+// ping checks connectivity to the database, backing off between failed attempts.
+func ping(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	backoff := 250 * time.Millisecond
+	for {
+		attemptCtx, attemptCancel := context.WithTimeout(ctx, 5*time.Second)
+		err := db.PingContext(attemptCtx)
+		attemptCancel()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database unreachable: %w", err)
+		case <-time.After(backoff):
+		}
+		if backoff < 5*time.Second {
+			backoff *= 2
+		}
 	}
-	query := `
-		SELECT id, name, description, start_date, target_end_date, actual_end_date, created_on, created_by, modified_on, modified_by, version
-		FROM project
-		WHERE id = $1`
-	var project model.Project
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+}
+
+// Stats returns the database connection pool statistics.
+func (r *Repository) Stats() sql.DBStats {
+	return r.db.Stats()
+}
+
+// Close closes the underlying database connection pool.
+func (r *Repository) Close() error {
+	return r.db.Close()
+}
+
+// projectColumns lists the columns selected for a project record, in the order read by scanProject.
+const projectColumns = `id, name, key, description, status, start_date, target_end_date, actual_end_date, created_on, created_by, modified_on, modified_by, version, deleted_on, COALESCE(deleted_by, 0), archived_on, COALESCE(archived_by, 0)`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProject reads the projectColumns of a row into project. Any extra
+// destinations are scanned first, for columns selected ahead of projectColumns.
+func scanProject(row rowScanner, project *model.Project, extra ...interface{}) error {
+	dest := append(extra,
 		&project.ID,
 		&project.Name,
+		&project.Key,
 		&project.Description,
+		&project.Status,
 		&project.StartDate,
 		&project.TargetEndDate,
 		&project.ActualEndDate,
@@ -58,58 +110,284 @@ func (r *Repository) Get(ctx context.Context, id int64) (*model.Project, error)
 		&project.ModifiedOn,
 		&project.ModifiedBy,
 		&project.Version,
+		&project.DeletedOn,
+		&project.DeletedBy,
+		&project.ArchivedOn,
+		&project.ArchivedBy,
 	)
+	return row.Scan(dest...)
+}
+
+// Create adds a new project record.
+func (r *Repository) Create(ctx context.Context, project *model.Project) error {
+	query := `
+			INSERT INTO project (name, key, description, status, start_date, target_end_date, created_by, modified_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		  	RETURNING id, created_on, version`
+	args := []interface{}{project.Name, project.Key, project.Description, project.Status, project.StartDate, project.TargetEndDate, project.CreatedBy, project.ModifiedBy}
+	err := r.executor(ctx).QueryRowContext(ctx, query, args...).Scan(&project.ID, &project.CreatedOn, &project.Version)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	return nil
+}
+
+// Get retrieves a project record by its id. Projects in the trash are not returned.
+func (r *Repository) Get(ctx context.Context, id int64) (*model.Project, error) {
+	if id < 1 {
+		return nil, repository.ErrNotFound
+	}
+	query := `
+		SELECT ` + projectColumns + `
+		FROM project
+		WHERE id = $1 AND deleted_on IS NULL`
+	var project model.Project
+	err := scanProject(r.executor(ctx).QueryRowContext(ctx, query, id), &project)
 	if err != nil {
 		switch {
-		case err.Error() == "pq: canceling statement due to user request":
-			return nil, fmt.Errorf("%v: %w", err, ctx.Err())
 		case errors.Is(err, sql.ErrNoRows):
 			return nil, repository.ErrNotFound
 		default:
-			return nil, err
+			return nil, translateError(ctx, err)
 		}
 	}
 	return &project, nil
 }
 
+// GetTrashed retrieves a project record in the trash by its id.
+func (r *Repository) GetTrashed(ctx context.Context, id int64) (*model.Project, error) {
+	if id < 1 {
+		return nil, repository.ErrNotFound
+	}
+	query := `
+		SELECT ` + projectColumns + `
+		FROM project
+		WHERE id = $1 AND deleted_on IS NOT NULL`
+	var project model.Project
+	err := scanProject(r.executor(ctx).QueryRowContext(ctx, query, id), &project)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrNotFound
+		default:
+			return nil, translateError(ctx, err)
+		}
+	}
+	return &project, nil
+}
+
+// GetByKey retrieves a project record by its key. Projects in the trash are not returned.
+func (r *Repository) GetByKey(ctx context.Context, key string) (*model.Project, error) {
+	query := `
+		SELECT ` + projectColumns + `
+		FROM project
+		WHERE key = $1 AND deleted_on IS NULL`
+	var project model.Project
+	err := scanProject(r.executor(ctx).QueryRowContext(ctx, query, key), &project)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrNotFound
+		default:
+			return nil, translateError(ctx, err)
+		}
+	}
+	return &project, nil
+}
+
+// KeyExists reports whether a project key is taken, including by projects in the trash.
+func (r *Repository) KeyExists(ctx context.Context, key string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM project WHERE key = $1)`
+	var exists bool
+	err := r.executor(ctx).QueryRowContext(ctx, query, key).Scan(&exists)
+	if err != nil {
+		return false, translateError(ctx, err)
+	}
+	return exists, nil
+}
+
+// GetAll retrieves a paginated list of project records matching the given query.
+// Sorting by relevance ranks records against the full-text search term. Projects
+// in the trash or the archive are only listed, exclusively, when the query asks for them.
+func (r *Repository) GetAll(ctx context.Context, q model.ProjectQuery, filters model.Filters) ([]*model.Project, model.Metadata, error) {
+	if filters.CursorMode {
+		return r.getAllByCursor(ctx, q, filters)
+	}
+	sortColumn := filters.SortColumn()
+	if sortColumn == "relevance" {
+		sortColumn = "ts_rank(search, plainto_tsquery('english', $2))"
+	}
+	conditions, args := listConditions(q)
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), %s
+		FROM project
+		WHERE %s
+		ORDER BY %s %s, id ASC
+		LIMIT $%d OFFSET $%d`, projectColumns, conditions, sortColumn, filters.SortDirection(), len(args)+1, len(args)+2)
+	args = append(args, filters.Limit(), filters.Offset())
+	rows, err := r.executor(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, model.Metadata{}, translateError(ctx, err)
+	}
+	defer rows.Close()
+	totalRecords := 0
+	projects := []*model.Project{}
+	for rows.Next() {
+		var project model.Project
+		err := scanProject(rows, &project, &totalRecords)
+		if err != nil {
+			return nil, model.Metadata{}, translateError(ctx, err)
+		}
+		projects = append(projects, &project)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, model.Metadata{}, translateError(ctx, err)
+	}
+	metadata := model.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return projects, metadata, nil
+}
+
+// getAllByCursor retrieves a page of project records positioned after the record
+// encoded in the filters cursor, or before it when the cursor points backward.
+// Records are ordered by the sort column with id as a tie-breaker, so that the
+// (column, id) pair identifies a stable position even while rows are inserted.
+func (r *Repository) getAllByCursor(ctx context.Context, q model.ProjectQuery, filters model.Filters) ([]*model.Project, model.Metadata, error) {
+	var cursor model.Cursor
+	if filters.Cursor != "" {
+		c, err := model.DecodeCursor(filters.Cursor)
+		if err != nil {
+			return nil, model.Metadata{}, err
+		}
+		cursor = c
+	}
+	sortColumn := filters.SortColumn()
+	direction := filters.SortDirection()
+	// A backward page is read in reverse order and flipped back afterwards.
+	if cursor.Backward {
+		if direction == "ASC" {
+			direction = "DESC"
+		} else {
+			direction = "ASC"
+		}
+	}
+	comparison := ">"
+	if direction == "DESC" {
+		comparison = "<"
+	}
+	conditions, args := listConditions(q)
+	if filters.Cursor != "" {
+		conditions += fmt.Sprintf(" AND (%s, id) %s ($%d, $%d)", sortColumn, comparison, len(args)+1, len(args)+2)
+		args = append(args, cursor.Value, cursor.ID)
+	}
+	// One extra record is fetched to find out whether another page follows.
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM project
+		WHERE %s
+		ORDER BY %s %s, id %s
+		LIMIT $%d`, projectColumns, conditions, sortColumn, direction, direction, len(args)+1)
+	args = append(args, filters.Limit()+1)
+	rows, err := r.executor(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, model.Metadata{}, translateError(ctx, err)
+	}
+	defer rows.Close()
+	projects := []*model.Project{}
+	for rows.Next() {
+		var project model.Project
+		err := scanProject(rows, &project)
+		if err != nil {
+			return nil, model.Metadata{}, translateError(ctx, err)
+		}
+		projects = append(projects, &project)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, model.Metadata{}, translateError(ctx, err)
+	}
+	hasMore := len(projects) > filters.Limit()
+	if hasMore {
+		projects = projects[:filters.Limit()]
+	}
+	hasNext, hasPrev := hasMore, filters.Cursor != ""
+	if cursor.Backward {
+		for i, j := 0, len(projects)-1; i < j; i, j = i+1, j-1 {
+			projects[i], projects[j] = projects[j], projects[i]
+		}
+		hasNext, hasPrev = true, hasMore
+	}
+	metadata := model.Metadata{PageSize: filters.PageSize}
+	if len(projects) > 0 {
+		first, last := projects[0], projects[len(projects)-1]
+		if hasNext {
+			metadata.NextCursor = model.EncodeCursor(model.Cursor{Sort: filters.Sort, Value: sortValue(last, sortColumn), ID: last.ID})
+		}
+		if hasPrev {
+			metadata.PrevCursor = model.EncodeCursor(model.Cursor{Sort: filters.Sort, Value: sortValue(first, sortColumn), ID: first.ID, Backward: true})
+		}
+	}
+	return projects, metadata, nil
+}
+
+// listConditions returns the WHERE conditions shared by the listing queries.
+// Their arguments are returned in placeholder order, starting from $1; the
+// full-text search term is always $2 so that relevance ranking can refer to it.
+func listConditions(q model.ProjectQuery) (string, []interface{}) {
+	conditions := `(LOWER(name) = LOWER($1) OR $1 = '')
+		AND (search @@ plainto_tsquery('english', $2) OR $2 = '')
+		AND (deleted_on IS NOT NULL) = $3
+		AND (archived_on IS NOT NULL) = $4
+		AND ($5::bigint = 0 OR id IN (SELECT project_id FROM project_member WHERE user_id = $5))`
+	return conditions, []interface{}{q.Name, q.Search, q.Trashed, q.Archived, q.MemberID}
+}
+
+// sortValue returns the value of a project's sort column in a form PostgreSQL
+// can compare against the column when it is passed back as a query argument.
+func sortValue(project *model.Project, column string) string {
+	switch column {
+	case "name":
+		return project.Name
+	case "created_on":
+		return project.CreatedOn.Format(time.RFC3339Nano)
+	case "modified_on":
+		return project.ModifiedOn.Format(time.RFC3339Nano)
+	default:
+		return strconv.FormatInt(project.ID, 10)
+	}
+}
+
 // Update updates a project record.
 func (r *Repository) Update(ctx context.Context, project *model.Project) error {
 	query := `
 		UPDATE project
-		SET name = $1, description = $2, start_date = $3, target_end_date = $4, actual_end_date = $5, modified_on = CURRENT_TIMESTAMP(0), modified_by = $6, version = version + 1
-		WHERE id = $7 AND version = $8
-		RETURNING version`
-	args := []interface{}{project.Name, project.Description, project.StartDate, project.TargetEndDate, project.ActualEndDate, project.ModifiedBy, project.ID, project.Version}
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&project.Version)
+		SET name = $1, description = $2, status = $3, start_date = $4, target_end_date = $5, actual_end_date = $6, archived_on = $7, archived_by = NULLIF($8, 0),
+			modified_on = CURRENT_TIMESTAMP(0), modified_by = $9, version = version + 1
+		WHERE id = $10 AND version = $11 AND deleted_on IS NULL
+		RETURNING modified_on, version`
+	args := []interface{}{project.Name, project.Description, project.Status, project.StartDate, project.TargetEndDate, project.ActualEndDate, project.ArchivedOn, project.ArchivedBy, project.ModifiedBy, project.ID, project.Version}
+	err := r.executor(ctx).QueryRowContext(ctx, query, args...).Scan(&project.ModifiedOn, &project.Version)
 	if err != nil {
 		switch {
-		case err.Error() == "pq: canceling statement due to user request":
-			return fmt.Errorf("%v: %w", err, ctx.Err())
 		case errors.Is(err, sql.ErrNoRows):
 			return repository.ErrEditConflict
 		default:
-			return err
+			return translateError(ctx, err)
 		}
 	}
 	return nil
 }
 
-// Delete removes a project record by its id.
-func (r *Repository) Delete(ctx context.Context, id int64) error {
+// Delete moves a project record to the trash by its id.
+func (r *Repository) Delete(ctx context.Context, id int64, deletedBy int64) error {
 	if id < 1 {
 		return repository.ErrNotFound
 	}
 	query := `
-		DELETE FROM project
-		WHERE id = $1`
-	result, err := r.db.ExecContext(ctx, query, id)
+		UPDATE project
+		SET deleted_on = CURRENT_TIMESTAMP(0), deleted_by = $2, modified_on = CURRENT_TIMESTAMP(0), modified_by = $2, version = version + 1
+		WHERE id = $1 AND deleted_on IS NULL`
+	result, err := r.executor(ctx).ExecContext(ctx, query, id, deletedBy)
 	if err != nil {
-		switch {
-		case err.Error() == "pq: canceling statement due to user request":
-			return fmt.Errorf("%v: %w", err, ctx.Err())
-		default:
-			return err
-		}
+		return translateError(ctx, err)
 	}
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
@@ -120,3 +398,39 @@ func (r *Repository) Delete(ctx context.Context, id int64) error {
 	}
 	return nil
 }
+
+// Restore moves a project record out of the trash by its id.
+func (r *Repository) Restore(ctx context.Context, id int64, restoredBy int64) (*model.Project, error) {
+	if id < 1 {
+		return nil, repository.ErrNotFound
+	}
+	query := `
+		UPDATE project
+		SET deleted_on = NULL, deleted_by = NULL, modified_on = CURRENT_TIMESTAMP(0), modified_by = $2, version = version + 1
+		WHERE id = $1 AND deleted_on IS NOT NULL
+		RETURNING ` + projectColumns
+	var project model.Project
+	err := scanProject(r.executor(ctx).QueryRowContext(ctx, query, id, restoredBy), &project)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrNotFound
+		default:
+			return nil, translateError(ctx, err)
+		}
+	}
+	return &project, nil
+}
+
+// Purge permanently removes project records that were moved to the trash
+// before the given time, and returns the number of records removed.
+func (r *Repository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM project
+		WHERE deleted_on < $1`
+	result, err := r.executor(ctx).ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+	return result.RowsAffected()
+}
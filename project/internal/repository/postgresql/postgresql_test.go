@@ -0,0 +1,40 @@
+package postgresql
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/emzola/venato/project/internal/migrate"
+	"github.com/emzola/venato/project/internal/repository/repotest"
+	"github.com/emzola/venato/project/migrations"
+)
+
+// TestRepository runs the repository conformance suite against the database named
+// by PROJECT_TEST_DATABASE_URL. Every table of that database is emptied between tests.
+func TestRepository(t *testing.T) {
+	dsn := os.Getenv("PROJECT_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("PROJECT_TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	db, err := Open(ctx, Config{URL: dsn, MaxOpenConns: 5, MaxIdleConns: 5, ConnectTimeout: 10 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	repotest.Run(t, func(t *testing.T) repotest.Repository {
+		if _, err := db.ExecContext(ctx, `TRUNCATE project, project_member, idempotency_key, project_audit RESTART IDENTITY CASCADE`); err != nil {
+			t.Fatal(err)
+		}
+		return New(db)
+	})
+}
@@ -0,0 +1,53 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+)
+
+// txKey is the context key under which RunInTx stores the active transaction.
+type txKey struct{}
+
+// executor is implemented by both *sql.DB and *sql.Tx.
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// executor returns the transaction started by RunInTx for the context, if any,
+// so that repository calls made within fn take part in it.
+func (r *Repository) executor(ctx context.Context) executor {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return r.db
+}
+
+// RunInTx calls fn with a context carrying a new transaction, which every repository
+// call made with that context joins. The transaction is committed if fn returns nil and
+// rolled back if it returns an error or panics. When ctx already carries a transaction,
+// fn joins it instead of starting a new one.
+func (r *Repository) RunInTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return translateError(ctx, err)
+	}
+	return nil
+}
@@ -0,0 +1,887 @@
+// Package repotest provides a conformance test suite that every project
+// repository implementation must pass, so that backends behave identically.
+package repotest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/emzola/venato/project/internal/repository"
+	"github.com/emzola/venato/project/pkg/model"
+)
+
+// Repository is the set of operations covered by the suite.
+type Repository interface {
+	RunInTx(ctx context.Context, fn func(ctx context.Context) error) error
+	Create(ctx context.Context, project *model.Project) error
+	Get(ctx context.Context, id int64) (*model.Project, error)
+	GetTrashed(ctx context.Context, id int64) (*model.Project, error)
+	GetByKey(ctx context.Context, key string) (*model.Project, error)
+	KeyExists(ctx context.Context, key string) (bool, error)
+	GetAll(ctx context.Context, query model.ProjectQuery, filters model.Filters) ([]*model.Project, model.Metadata, error)
+	Update(ctx context.Context, project *model.Project) error
+	Delete(ctx context.Context, id int64, deletedBy int64) error
+	Restore(ctx context.Context, id int64, restoredBy int64) (*model.Project, error)
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	AddMember(ctx context.Context, member *model.Member) error
+	GetMember(ctx context.Context, projectID, userID int64) (*model.Member, error)
+	GetMembers(ctx context.Context, projectID int64) ([]*model.Member, error)
+	UpdateMember(ctx context.Context, member *model.Member) error
+	RemoveMember(ctx context.Context, projectID, userID int64) error
+	CountOwners(ctx context.Context, projectID int64) (int, error)
+	CreateIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) error
+	GetIdempotencyKey(ctx context.Context, userID int64, key string) (*model.IdempotencyKey, error)
+	PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error)
+	CreateAuditEntry(ctx context.Context, entry *model.AuditEntry) error
+	GetAuditEntry(ctx context.Context, projectID, version int64) (*model.AuditEntry, error)
+	GetAuditEntryAt(ctx context.Context, projectID int64, at time.Time) (*model.AuditEntry, error)
+	GetHistory(ctx context.Context, projectID int64, filters model.Filters) ([]*model.AuditEntry, model.Metadata, error)
+}
+
+// sortSafelist mirrors the sort values accepted by the project controller.
+var sortSafelist = []string{"id", "name", "created_on", "modified_on", "relevance", "-id", "-name", "-created_on", "-modified_on", "-relevance"}
+
+// Run runs the conformance suite. newRepository must return an empty repository
+// each time it is called.
+func Run(t *testing.T, newRepository func(t *testing.T) Repository) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo Repository)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"GetNotFound", testGetNotFound},
+		{"DuplicateKey", testDuplicateKey},
+		{"Update", testUpdate},
+		{"UpdateConflict", testUpdateConflict},
+		{"DeleteAndRestore", testDeleteAndRestore},
+		{"Purge", testPurge},
+		{"ListPagination", testListPagination},
+		{"ListFilters", testListFilters},
+		{"ListCursor", testListCursor},
+		{"Members", testMembers},
+		{"IdempotencyKeys", testIdempotencyKeys},
+		{"History", testHistory},
+		{"ContextCanceled", testContextCanceled},
+		{"TxCommit", testTxCommit},
+		{"TxRollback", testTxRollback},
+		{"TxPanic", testTxPanic},
+		{"TxRollbackKeepsOtherChanges", testTxRollbackKeepsOtherChanges},
+		{"CheckConstraints", testCheckConstraints},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepository(t))
+		})
+	}
+}
+
+// newProject returns a valid project that has not been stored yet. Dates are
+// truncated to the one second precision guaranteed by every backend.
+func newProject(name, key string) *model.Project {
+	start := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	return &model.Project{
+		Name:          name,
+		Key:           key,
+		Description:   "Description of " + name,
+		Status:        model.StatusPlanned,
+		StartDate:     start,
+		TargetEndDate: start.Add(30 * 24 * time.Hour),
+		CreatedBy:     1,
+		ModifiedBy:    1,
+	}
+}
+
+// mustCreate stores a new project and fails the test on error.
+func mustCreate(t *testing.T, repo Repository, name, key string) *model.Project {
+	t.Helper()
+	project := newProject(name, key)
+	if err := repo.Create(context.Background(), project); err != nil {
+		t.Fatalf("Create(%q): %v", key, err)
+	}
+	return project
+}
+
+// mustGet retrieves a stored project and fails the test on error. Updates start
+// from a retrieved project, as the controller does, since Create does not return
+// every column.
+func mustGet(t *testing.T, repo Repository, id int64) *model.Project {
+	t.Helper()
+	project, err := repo.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get(%d): %v", id, err)
+	}
+	return project
+}
+
+func testCreateAndGet(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	first := mustCreate(t, repo, "Apollo", "APL")
+	second := mustCreate(t, repo, "Gemini", "GEM")
+	if first.ID < 1 || second.ID <= first.ID {
+		t.Fatalf("got ids %d and %d, want increasing ids starting from 1", first.ID, second.ID)
+	}
+	if first.Version != 1 {
+		t.Errorf("got version %d, want 1", first.Version)
+	}
+	if first.CreatedOn.IsZero() {
+		t.Error("created_on was not set")
+	}
+	got, err := repo.Get(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Name != first.Name || got.Key != first.Key || got.Description != first.Description || got.Status != first.Status {
+		t.Errorf("got %+v, want %+v", got, first)
+	}
+	if !got.StartDate.Equal(first.StartDate) || !got.TargetEndDate.Equal(first.TargetEndDate) {
+		t.Errorf("got dates %v-%v, want %v-%v", got.StartDate, got.TargetEndDate, first.StartDate, first.TargetEndDate)
+	}
+	if got.CreatedBy != first.CreatedBy || got.Version != first.Version {
+		t.Errorf("got created_by %d version %d, want %d and %d", got.CreatedBy, got.Version, first.CreatedBy, first.Version)
+	}
+	if got.ActualEndDate != nil {
+		t.Errorf("got actual_end_date %v, want none until the project completes", got.ActualEndDate)
+	}
+	// Projects may be planned to start in the future.
+	planned := newProject("Artemis", "ART")
+	planned.StartDate = time.Now().Add(7 * 24 * time.Hour).Truncate(time.Second)
+	planned.TargetEndDate = planned.StartDate.Add(30 * 24 * time.Hour)
+	if err := repo.Create(ctx, planned); err != nil {
+		t.Errorf("Create of a project starting in the future: %v", err)
+	}
+	got, err = repo.GetByKey(ctx, "GEM")
+	if err != nil {
+		t.Fatalf("GetByKey: %v", err)
+	}
+	if got.ID != second.ID {
+		t.Errorf("GetByKey returned project %d, want %d", got.ID, second.ID)
+	}
+}
+
+func testGetNotFound(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	project := mustCreate(t, repo, "Apollo", "APL")
+	for _, id := range []int64{-1, 0, project.ID + 1000} {
+		if _, err := repo.Get(ctx, id); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Get(%d): got error %v, want ErrNotFound", id, err)
+		}
+		if err := repo.Delete(ctx, id, 1); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Delete(%d): got error %v, want ErrNotFound", id, err)
+		}
+		if _, err := repo.Restore(ctx, id, 1); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Restore(%d): got error %v, want ErrNotFound", id, err)
+		}
+	}
+	if _, err := repo.GetByKey(ctx, "NOPE"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetByKey: got error %v, want ErrNotFound", err)
+	}
+}
+
+func testDuplicateKey(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	project := mustCreate(t, repo, "Apollo", "APL")
+	if err := repo.Create(ctx, newProject("Other", "APL")); !errors.Is(err, repository.ErrDuplicateKey) {
+		t.Errorf("got error %v, want ErrDuplicateKey", err)
+	}
+	if err := repo.Delete(ctx, project.ID, 1); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	// Keys stay reserved while their project is in the trash.
+	exists, err := repo.KeyExists(ctx, "APL")
+	if err != nil || !exists {
+		t.Errorf("KeyExists(APL) = %v, %v; want true", exists, err)
+	}
+	exists, err = repo.KeyExists(ctx, "GEM")
+	if err != nil || exists {
+		t.Errorf("KeyExists(GEM) = %v, %v; want false", exists, err)
+	}
+}
+
+func testUpdate(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	project := mustGet(t, repo, mustCreate(t, repo, "Apollo", "APL").ID)
+	project.Name = "Apollo 11"
+	project.Description = "Moon landing"
+	project.Status = model.StatusActive
+	project.TargetEndDate = project.TargetEndDate.Add(24 * time.Hour)
+	project.ModifiedBy = 2
+	if err := repo.Update(ctx, project); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if project.Version != 2 {
+		t.Errorf("got version %d, want 2", project.Version)
+	}
+	got, err := repo.Get(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Name != "Apollo 11" || got.Description != "Moon landing" || got.Status != model.StatusActive || got.ModifiedBy != 2 {
+		t.Errorf("update was not stored: %+v", got)
+	}
+	if !got.TargetEndDate.Equal(project.TargetEndDate) || got.Version != 2 || got.Key != "APL" {
+		t.Errorf("update was not stored: %+v", got)
+	}
+	actualEndDate := time.Now().Truncate(time.Second)
+	got.Status, got.ActualEndDate = model.StatusCompleted, &actualEndDate
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err = repo.Get(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.ActualEndDate == nil || !got.ActualEndDate.Equal(actualEndDate) {
+		t.Errorf("got actual_end_date %v, want %v", got.ActualEndDate, actualEndDate)
+	}
+	archivedOn := time.Now().Truncate(time.Second)
+	got.ArchivedOn, got.ArchivedBy = &archivedOn, 3
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err = repo.Get(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.ArchivedOn == nil || !got.ArchivedOn.Equal(archivedOn) || got.ArchivedBy != 3 {
+		t.Errorf("got archived %v by %d, want %v by 3", got.ArchivedOn, got.ArchivedBy, archivedOn)
+	}
+}
+
+func testUpdateConflict(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	project := mustCreate(t, repo, "Apollo", "APL")
+	first, err := repo.Get(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	second, err := repo.Get(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	first.Name = "First"
+	if err := repo.Update(ctx, first); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	second.Name = "Second"
+	if err := repo.Update(ctx, second); !errors.Is(err, repository.ErrEditConflict) {
+		t.Errorf("got error %v, want ErrEditConflict", err)
+	}
+	got, err := repo.Get(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Name != "First" {
+		t.Errorf("got name %q, want First", got.Name)
+	}
+	if err := repo.Delete(ctx, project.ID, 1); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.Update(ctx, got); !errors.Is(err, repository.ErrEditConflict) {
+		t.Errorf("updating a trashed project: got error %v, want ErrEditConflict", err)
+	}
+}
+
+func testDeleteAndRestore(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	project := mustCreate(t, repo, "Apollo", "APL")
+	if err := repo.Delete(ctx, project.ID, 2); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.Get(ctx, project.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Get after Delete: got error %v, want ErrNotFound", err)
+	}
+	if _, err := repo.GetByKey(ctx, "APL"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetByKey after Delete: got error %v, want ErrNotFound", err)
+	}
+	if got, err := repo.GetTrashed(ctx, project.ID); err != nil || got.DeletedOn == nil || got.DeletedBy != 2 || got.Version != 2 ||
+		got.ModifiedBy != 2 || !got.ModifiedOn.Equal(*got.DeletedOn) {
+		t.Errorf("GetTrashed after Delete = %+v, %v; want the deleted project", got, err)
+	}
+	if err := repo.Delete(ctx, project.ID, 2); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("second Delete: got error %v, want ErrNotFound", err)
+	}
+	trashed, _, err := repo.GetAll(ctx, model.ProjectQuery{Trashed: true}, pageFilters(1, 10, "id"))
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(trashed) != 1 || trashed[0].DeletedOn == nil || trashed[0].DeletedBy != 2 {
+		t.Fatalf("got trash %+v, want the deleted project", trashed)
+	}
+	restored, err := repo.Restore(ctx, project.ID, 3)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored.DeletedOn != nil || restored.DeletedBy != 0 || restored.ModifiedBy != 3 {
+		t.Errorf("restored project still marked as deleted: %+v", restored)
+	}
+	if restored.Version != 3 {
+		t.Errorf("got version %d, want 3", restored.Version)
+	}
+	if _, err := repo.Restore(ctx, project.ID, 3); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("second Restore: got error %v, want ErrNotFound", err)
+	}
+	if _, err := repo.Get(ctx, project.ID); err != nil {
+		t.Errorf("Get after Restore: %v", err)
+	}
+	if _, err := repo.GetTrashed(ctx, project.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetTrashed after Restore: got error %v, want ErrNotFound", err)
+	}
+}
+
+func testPurge(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	kept := mustCreate(t, repo, "Apollo", "APL")
+	purged := mustCreate(t, repo, "Gemini", "GEM")
+	if err := repo.AddMember(ctx, &model.Member{ProjectID: purged.ID, UserID: 1, Role: model.RoleOwner, AddedBy: 1}); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	if err := repo.Delete(ctx, purged.ID, 1); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	n, err := repo.Purge(ctx, time.Now().Add(-time.Hour))
+	if err != nil || n != 0 {
+		t.Errorf("Purge before deletion = %d, %v; want 0", n, err)
+	}
+	n, err = repo.Purge(ctx, time.Now().Add(time.Hour))
+	if err != nil || n != 1 {
+		t.Errorf("Purge after deletion = %d, %v; want 1", n, err)
+	}
+	if _, err := repo.Restore(ctx, purged.ID, 1); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Restore after Purge: got error %v, want ErrNotFound", err)
+	}
+	if _, err := repo.GetMember(ctx, purged.ID, 1); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetMember after Purge: got error %v, want ErrNotFound", err)
+	}
+	if _, err := repo.Get(ctx, kept.ID); err != nil {
+		t.Errorf("Get of a project outside the trash: %v", err)
+	}
+}
+
+func pageFilters(page, pageSize int, sort string) model.Filters {
+	return model.Filters{Page: page, PageSize: pageSize, Sort: sort, SortSafelist: sortSafelist}
+}
+
+// names returns the names of projects in order.
+func names(projects []*model.Project) []string {
+	names := make([]string, len(projects))
+	for i, project := range projects {
+		names[i] = project.Name
+	}
+	return names
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testListPagination(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	for i, name := range []string{"delta", "alpha", "echo", "charlie", "bravo"} {
+		mustCreate(t, repo, name, fmt.Sprintf("P%d", i+1))
+	}
+	tests := []struct {
+		sort     string
+		page     int
+		pageSize int
+		want     []string
+	}{
+		{"id", 1, 2, []string{"delta", "alpha"}},
+		{"id", 3, 2, []string{"bravo"}},
+		{"-id", 1, 3, []string{"bravo", "charlie", "echo"}},
+		{"name", 1, 3, []string{"alpha", "bravo", "charlie"}},
+		{"name", 2, 3, []string{"delta", "echo"}},
+		{"-name", 1, 2, []string{"echo", "delta"}},
+		{"id", 4, 2, []string{}},
+	}
+	for _, tt := range tests {
+		projects, metadata, err := repo.GetAll(ctx, model.ProjectQuery{}, pageFilters(tt.page, tt.pageSize, tt.sort))
+		if err != nil {
+			t.Fatalf("GetAll(sort=%s, page=%d): %v", tt.sort, tt.page, err)
+		}
+		if got := names(projects); !equal(got, tt.want) {
+			t.Errorf("GetAll(sort=%s, page=%d) = %v, want %v", tt.sort, tt.page, got, tt.want)
+		}
+		if len(tt.want) == 0 {
+			continue
+		}
+		wantLast := (5 + tt.pageSize - 1) / tt.pageSize
+		if metadata.TotalRecords != 5 || metadata.CurrentPage != tt.page || metadata.LastPage != wantLast {
+			t.Errorf("GetAll(sort=%s, page=%d) metadata = %+v, want 5 records on %d pages", tt.sort, tt.page, metadata, wantLast)
+		}
+	}
+}
+
+func testListFilters(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	mustCreate(t, repo, "Rocket", "RKT")
+	garden := mustGet(t, repo, mustCreate(t, repo, "Garden", "GDN").ID)
+	garden.Description = "Rocket garden"
+	if err := repo.Update(ctx, garden); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	archived := mustGet(t, repo, mustCreate(t, repo, "Museum", "MSM").ID)
+	archivedOn := time.Now().Truncate(time.Second)
+	archived.ArchivedOn, archived.ArchivedBy = &archivedOn, 1
+	if err := repo.Update(ctx, archived); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := repo.AddMember(ctx, &model.Member{ProjectID: garden.ID, UserID: 7, Role: model.RoleViewer, AddedBy: 1}); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	tests := []struct {
+		name  string
+		query model.ProjectQuery
+		sort  string
+		want  []string
+	}{
+		{"name ignores case", model.ProjectQuery{Name: "rocket"}, "id", []string{"Rocket"}},
+		{"search", model.ProjectQuery{Search: "rocket"}, "id", []string{"Rocket", "Garden"}},
+		{"search ranks name matches first", model.ProjectQuery{Search: "rocket"}, "-relevance", []string{"Rocket", "Garden"}},
+		{"search requires every word", model.ProjectQuery{Search: "rocket garden"}, "id", []string{"Garden"}},
+		{"archive", model.ProjectQuery{Archived: true}, "id", []string{"Museum"}},
+		{"membership", model.ProjectQuery{MemberID: 7}, "id", []string{"Garden"}},
+		{"no match", model.ProjectQuery{Name: "Nothing"}, "id", []string{}},
+	}
+	for _, tt := range tests {
+		projects, _, err := repo.GetAll(ctx, tt.query, pageFilters(1, 10, tt.sort))
+		if err != nil {
+			t.Fatalf("%s: GetAll: %v", tt.name, err)
+		}
+		if got := names(projects); !equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func testListCursor(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	for i, name := range []string{"delta", "alpha", "echo", "charlie", "bravo"} {
+		mustCreate(t, repo, name, fmt.Sprintf("P%d", i+1))
+	}
+	filters := pageFilters(1, 2, "name")
+	filters.CursorMode = true
+	var pages [][]string
+	var prev string
+	for {
+		projects, metadata, err := repo.GetAll(ctx, model.ProjectQuery{}, filters)
+		if err != nil {
+			t.Fatalf("GetAll: %v", err)
+		}
+		pages = append(pages, names(projects))
+		if (filters.Cursor == "") != (metadata.PrevCursor == "") {
+			t.Errorf("page %d: got prev cursor %q", len(pages), metadata.PrevCursor)
+		}
+		if metadata.NextCursor == "" {
+			prev = metadata.PrevCursor
+			break
+		}
+		if len(pages) > 5 {
+			t.Fatal("cursor pagination does not terminate")
+		}
+		filters.Cursor = metadata.NextCursor
+	}
+	want := [][]string{{"alpha", "bravo"}, {"charlie", "delta"}, {"echo"}}
+	if fmt.Sprint(pages) != fmt.Sprint(want) {
+		t.Fatalf("got pages %v, want %v", pages, want)
+	}
+	filters.Cursor = prev
+	projects, metadata, err := repo.GetAll(ctx, model.ProjectQuery{}, filters)
+	if err != nil {
+		t.Fatalf("GetAll backward: %v", err)
+	}
+	if got := names(projects); !equal(got, want[1]) {
+		t.Errorf("backward page = %v, want %v", got, want[1])
+	}
+	if metadata.NextCursor == "" || metadata.PrevCursor == "" {
+		t.Errorf("backward page metadata = %+v, want both cursors", metadata)
+	}
+}
+
+func testMembers(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	project := mustCreate(t, repo, "Apollo", "APL")
+	owner := &model.Member{ProjectID: project.ID, UserID: 1, Role: model.RoleOwner, AddedBy: 1}
+	if err := repo.AddMember(ctx, owner); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	if owner.AddedOn.IsZero() {
+		t.Error("added_on was not set")
+	}
+	viewer := &model.Member{ProjectID: project.ID, UserID: 2, Role: model.RoleViewer, AddedBy: 1}
+	if err := repo.AddMember(ctx, viewer); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	if err := repo.AddMember(ctx, &model.Member{ProjectID: project.ID, UserID: 2, Role: model.RoleAdmin, AddedBy: 1}); !errors.Is(err, repository.ErrDuplicateMember) {
+		t.Errorf("duplicate AddMember: got error %v, want ErrDuplicateMember", err)
+	}
+	if err := repo.AddMember(ctx, &model.Member{ProjectID: project.ID + 1000, UserID: 2, Role: model.RoleAdmin, AddedBy: 1}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("AddMember to a missing project: got error %v, want ErrNotFound", err)
+	}
+	members, err := repo.GetMembers(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("GetMembers: %v", err)
+	}
+	if len(members) != 2 || members[0].UserID != 1 || members[1].UserID != 2 {
+		t.Errorf("got members %+v, want users 1 and 2", members)
+	}
+	viewer.Role = model.RoleOwner
+	if err := repo.UpdateMember(ctx, viewer); err != nil {
+		t.Fatalf("UpdateMember: %v", err)
+	}
+	if owners, err := repo.CountOwners(ctx, project.ID); err != nil || owners != 2 {
+		t.Errorf("CountOwners = %d, %v; want 2", owners, err)
+	}
+	got, err := repo.GetMember(ctx, project.ID, 2)
+	if err != nil || got.Role != model.RoleOwner {
+		t.Errorf("GetMember = %+v, %v; want an owner", got, err)
+	}
+	if err := repo.RemoveMember(ctx, project.ID, 2); err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+	if err := repo.RemoveMember(ctx, project.ID, 2); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("second RemoveMember: got error %v, want ErrNotFound", err)
+	}
+	if err := repo.UpdateMember(ctx, viewer); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("UpdateMember of a removed member: got error %v, want ErrNotFound", err)
+	}
+	if _, err := repo.GetMember(ctx, 0, 1); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetMember(0, 1): got error %v, want ErrNotFound", err)
+	}
+}
+
+func testIdempotencyKeys(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	stored := []byte(`{"project": {"id": 1, "name": "Apollo", "key": "APL", "tags": ["a", "b"]}}`)
+	key := &model.IdempotencyKey{UserID: 1, Key: "retry-1", RequestHash: "a", Response: stored, ExpiresOn: time.Now().Add(time.Hour)}
+	if err := repo.CreateIdempotencyKey(ctx, key); err != nil {
+		t.Fatalf("CreateIdempotencyKey: %v", err)
+	}
+	if key.CreatedOn.IsZero() {
+		t.Error("created_on was not set")
+	}
+	got, err := repo.GetIdempotencyKey(ctx, 1, "retry-1")
+	if err != nil {
+		t.Fatalf("GetIdempotencyKey: %v", err)
+	}
+	if got.RequestHash != "a" {
+		t.Errorf("got request hash %q, want a", got.RequestHash)
+	}
+	// The response may be re-encoded by the backend, so it is compared as decoded JSON.
+	var want, response interface{}
+	if err := json.Unmarshal(stored, &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(got.Response, &response); err != nil || !reflect.DeepEqual(response, want) {
+		t.Errorf("got response %s, %v; want %s", got.Response, err, stored)
+	}
+	duplicate := &model.IdempotencyKey{UserID: 1, Key: "retry-1", RequestHash: "b", Response: []byte(`{}`), ExpiresOn: time.Now().Add(time.Hour)}
+	if err := repo.CreateIdempotencyKey(ctx, duplicate); !errors.Is(err, repository.ErrDuplicateIdempotencyKey) {
+		t.Errorf("duplicate CreateIdempotencyKey: got error %v, want ErrDuplicateIdempotencyKey", err)
+	}
+	// Keys are scoped to the user who sent them.
+	if _, err := repo.GetIdempotencyKey(ctx, 2, "retry-1"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetIdempotencyKey of another user: got error %v, want ErrNotFound", err)
+	}
+	other := &model.IdempotencyKey{UserID: 2, Key: "retry-1", RequestHash: "c", Response: []byte(`{}`), ExpiresOn: time.Now().Add(time.Hour)}
+	if err := repo.CreateIdempotencyKey(ctx, other); err != nil {
+		t.Errorf("CreateIdempotencyKey of another user: %v", err)
+	}
+	expired := &model.IdempotencyKey{UserID: 1, Key: "retry-2", RequestHash: "a", Response: []byte(`{}`), ExpiresOn: time.Now().Add(-time.Hour)}
+	if err := repo.CreateIdempotencyKey(ctx, expired); err != nil {
+		t.Fatalf("CreateIdempotencyKey: %v", err)
+	}
+	if _, err := repo.GetIdempotencyKey(ctx, 1, "retry-2"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetIdempotencyKey of an expired key: got error %v, want ErrNotFound", err)
+	}
+	// An expired key may be used again.
+	reused := &model.IdempotencyKey{UserID: 1, Key: "retry-2", RequestHash: "b", Response: []byte(`{}`), ExpiresOn: time.Now().Add(time.Hour)}
+	if err := repo.CreateIdempotencyKey(ctx, reused); err != nil {
+		t.Errorf("CreateIdempotencyKey of an expired key: %v", err)
+	}
+	if got, err := repo.GetIdempotencyKey(ctx, 1, "retry-2"); err != nil || got.RequestHash != "b" {
+		t.Errorf("GetIdempotencyKey of a reused key = %+v, %v; want request hash b", got, err)
+	}
+	expired.Key = "retry-3"
+	if err := repo.CreateIdempotencyKey(ctx, expired); err != nil {
+		t.Fatalf("CreateIdempotencyKey: %v", err)
+	}
+	n, err := repo.PurgeIdempotencyKeys(ctx, time.Now())
+	if err != nil || n != 1 {
+		t.Errorf("PurgeIdempotencyKeys = %d, %v; want 1", n, err)
+	}
+	if _, err := repo.GetIdempotencyKey(ctx, 1, "retry-1"); err != nil {
+		t.Errorf("GetIdempotencyKey after PurgeIdempotencyKeys: %v", err)
+	}
+}
+
+func testHistory(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	for version := int64(1); version <= 3; version++ {
+		entry := &model.AuditEntry{
+			ProjectID: 1,
+			Version:   version,
+			Action:    model.AuditUpdate,
+			Actor:     2,
+			Changes:   map[string]model.Change{"name": {Before: "Apollo", After: fmt.Sprintf("Apollo %d", version)}},
+			Snapshot:  &model.Project{ID: 1, Name: fmt.Sprintf("Apollo %d", version), Key: "APL", Version: version},
+		}
+		if err := repo.CreateAuditEntry(ctx, entry); err != nil {
+			t.Fatalf("CreateAuditEntry: %v", err)
+		}
+		if entry.ID == 0 || entry.CreatedOn.IsZero() {
+			t.Errorf("id and created_on were not set: %+v", entry)
+		}
+	}
+	other := &model.AuditEntry{ProjectID: 2, Version: 1, Action: model.AuditCreate, Actor: 2, Changes: map[string]model.Change{}}
+	if err := repo.CreateAuditEntry(ctx, other); err != nil {
+		t.Fatalf("CreateAuditEntry: %v", err)
+	}
+	filters := model.Filters{Page: 1, PageSize: 2, Sort: "-version", SortSafelist: []string{"version", "-version"}}
+	entries, metadata, err := repo.GetHistory(ctx, 1, filters)
+	if err != nil {
+		t.Fatalf("GetHistory: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Version != 3 || entries[1].Version != 2 {
+		t.Fatalf("got entries %+v, want versions 3 and 2", entries)
+	}
+	if metadata.TotalRecords != 3 || metadata.LastPage != 2 {
+		t.Errorf("got metadata %+v, want 3 records over 2 pages", metadata)
+	}
+	change := entries[0].Changes["name"]
+	if entries[0].Action != model.AuditUpdate || entries[0].Actor != 2 || change.Before != "Apollo" || change.After != "Apollo 3" {
+		t.Errorf("got entry %+v, want the stored entry", entries[0])
+	}
+	filters.Sort = "version"
+	entries, _, err = repo.GetHistory(ctx, 1, filters)
+	if err != nil || len(entries) != 2 || entries[0].Version != 1 {
+		t.Errorf("GetHistory by ascending version = %+v, %v; want versions 1 and 2", entries, err)
+	}
+	entries, metadata, err = repo.GetHistory(ctx, 3, filters)
+	if err != nil || len(entries) != 0 || metadata.TotalRecords != 0 {
+		t.Errorf("GetHistory of a project without entries = %+v, %+v, %v; want none", entries, metadata, err)
+	}
+	entry, err := repo.GetAuditEntry(ctx, 1, 2)
+	if err != nil {
+		t.Fatalf("GetAuditEntry: %v", err)
+	}
+	if entry.Version != 2 || entry.Snapshot == nil || entry.Snapshot.Name != "Apollo 2" || entry.Snapshot.Version != 2 {
+		t.Errorf("got entry %+v, want version 2 with its snapshot", entry)
+	}
+	entry, err = repo.GetAuditEntry(ctx, 2, 1)
+	if err != nil || entry.Snapshot != nil {
+		t.Errorf("GetAuditEntry without a snapshot = %+v, %v; want a nil snapshot", entry, err)
+	}
+	if _, err := repo.GetAuditEntry(ctx, 1, 4); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetAuditEntry of a missing version = %v, want ErrNotFound", err)
+	}
+	entry, err = repo.GetAuditEntryAt(ctx, 1, time.Now().Add(time.Hour))
+	if err != nil || entry.Version != 3 {
+		t.Errorf("GetAuditEntryAt after the last change = %+v, %v; want version 3", entry, err)
+	}
+	if _, err := repo.GetAuditEntryAt(ctx, 1, time.Now().Add(-time.Hour)); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetAuditEntryAt before the first change = %v, want ErrNotFound", err)
+	}
+}
+
+func testContextCanceled(t *testing.T, repo Repository) {
+	project := mustCreate(t, repo, "Apollo", "APL")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	check := func(op string, err error) {
+		t.Helper()
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: got error %v, want context.Canceled", op, err)
+		}
+	}
+	check("Create", repo.Create(ctx, newProject("Gemini", "GEM")))
+	_, err := repo.Get(ctx, project.ID)
+	check("Get", err)
+	_, err = repo.GetByKey(ctx, project.Key)
+	check("GetByKey", err)
+	_, _, err = repo.GetAll(ctx, model.ProjectQuery{}, pageFilters(1, 10, "id"))
+	check("GetAll", err)
+	check("Update", repo.Update(ctx, project))
+	check("Delete", repo.Delete(ctx, project.ID, 1))
+	_, err = repo.GetMembers(ctx, project.ID)
+	check("GetMembers", err)
+	// Nothing must have been written by the canceled calls.
+	got, err := repo.Get(context.Background(), project.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Version != project.Version {
+		t.Errorf("got version %d, want %d", got.Version, project.Version)
+	}
+	if exists, _ := repo.KeyExists(context.Background(), "GEM"); exists {
+		t.Error("Create with a canceled context stored the project")
+	}
+}
+
+func testTxCommit(t *testing.T, repo Repository) {
+	var project *model.Project
+	err := repo.RunInTx(context.Background(), func(ctx context.Context) error {
+		project = newProject("Apollo", "APL")
+		if err := repo.Create(ctx, project); err != nil {
+			return err
+		}
+		// Nested calls join the enclosing transaction.
+		return repo.RunInTx(ctx, func(ctx context.Context) error {
+			if _, err := repo.Get(ctx, project.ID); err != nil {
+				return err
+			}
+			return repo.AddMember(ctx, &model.Member{ProjectID: project.ID, UserID: 1, Role: model.RoleOwner, AddedBy: 1})
+		})
+	})
+	if err != nil {
+		t.Fatalf("RunInTx: %v", err)
+	}
+	mustGet(t, repo, project.ID)
+	if _, err := repo.GetMember(context.Background(), project.ID, 1); err != nil {
+		t.Errorf("GetMember: %v", err)
+	}
+}
+
+func testTxRollback(t *testing.T, repo Repository) {
+	kept := mustCreate(t, repo, "Gemini", "GEM")
+	errAbort := errors.New("abort")
+	var project *model.Project
+	err := repo.RunInTx(context.Background(), func(ctx context.Context) error {
+		project = newProject("Apollo", "APL")
+		if err := repo.Create(ctx, project); err != nil {
+			return err
+		}
+		if err := repo.AddMember(ctx, &model.Member{ProjectID: project.ID, UserID: 1, Role: model.RoleOwner, AddedBy: 1}); err != nil {
+			return err
+		}
+		if err := repo.Update(ctx, mustGetIn(ctx, t, repo, kept.ID, "Changed")); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("RunInTx: got error %v, want the error returned by fn", err)
+	}
+	assertRolledBack(t, repo, project, kept)
+}
+
+func testTxPanic(t *testing.T, repo Repository) {
+	kept := mustCreate(t, repo, "Gemini", "GEM")
+	var project *model.Project
+	func() {
+		defer func() {
+			if p := recover(); p != "abort" {
+				t.Fatalf("recovered %v, want the panic raised by fn", p)
+			}
+		}()
+		repo.RunInTx(context.Background(), func(ctx context.Context) error {
+			project = newProject("Apollo", "APL")
+			if err := repo.Create(ctx, project); err != nil {
+				return err
+			}
+			if err := repo.Update(ctx, mustGetIn(ctx, t, repo, kept.ID, "Changed")); err != nil {
+				return err
+			}
+			panic("abort")
+		})
+	}()
+	assertRolledBack(t, repo, project, kept)
+}
+
+func testTxRollbackKeepsOtherChanges(t *testing.T, repo Repository) {
+	kept := mustCreate(t, repo, "Gemini", "GEM")
+	errAbort := errors.New("abort")
+	var project, other *model.Project
+	err := repo.RunInTx(context.Background(), func(ctx context.Context) error {
+		project = newProject("Apollo", "APL")
+		if err := repo.Create(ctx, project); err != nil {
+			return err
+		}
+		// Changes made outside of the transaction are committed on their own.
+		other = mustCreate(t, repo, "Mercury", "MER")
+		member := &model.Member{ProjectID: kept.ID, UserID: 2, Role: model.RoleMember, AddedBy: 1}
+		if err := repo.AddMember(context.Background(), member); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("RunInTx: got error %v, want the error returned by fn", err)
+	}
+	if exists, err := repo.KeyExists(context.Background(), project.Key); err != nil || exists {
+		t.Errorf("KeyExists(%s) = %v, %v; want false after rollback", project.Key, exists, err)
+	}
+	mustGet(t, repo, other.ID)
+	if _, err := repo.GetMember(context.Background(), kept.ID, 2); err != nil {
+		t.Errorf("GetMember of a member added outside of the transaction: %v", err)
+	}
+}
+
+func testCheckConstraints(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	assertCheck := func(name string, err error, constraint string) {
+		t.Helper()
+		var constraintErr *repository.ConstraintError
+		if !errors.As(err, &constraintErr) || !errors.Is(err, repository.ErrCheckViolation) || constraintErr.Constraint != constraint {
+			t.Errorf("%s: got error %v, want a violation of %s", name, err, constraint)
+		}
+	}
+	project := newProject("Apollo", "APL")
+	project.TargetEndDate = project.StartDate.Add(-time.Hour)
+	assertCheck("Create ending before it starts", repo.Create(ctx, project), "project_target_end_date_check")
+	project = newProject("Apollo", "APL")
+	project.Status = "unknown"
+	assertCheck("Create with an unknown status", repo.Create(ctx, project), "project_status_check")
+	project = mustGet(t, repo, mustCreate(t, repo, "Apollo", "APL").ID)
+	actualEndDate := project.StartDate.Add(-time.Hour)
+	project.Status, project.ActualEndDate = model.StatusCompleted, &actualEndDate
+	assertCheck("Update completed before it starts", repo.Update(ctx, project), "project_actual_end_date_check")
+	member := &model.Member{ProjectID: project.ID, UserID: 2, Role: "unknown", AddedBy: 1}
+	assertCheck("AddMember with an unknown role", repo.AddMember(ctx, member), "project_member_role_check")
+	member.Role = model.RoleMember
+	if err := repo.AddMember(ctx, member); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	member.Role = "unknown"
+	assertCheck("UpdateMember with an unknown role", repo.UpdateMember(ctx, member), "project_member_role_check")
+}
+
+// mustGetIn retrieves a stored project within a transaction and renames it.
+func mustGetIn(ctx context.Context, t *testing.T, repo Repository, id int64, name string) *model.Project {
+	t.Helper()
+	project, err := repo.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("Get(%d): %v", id, err)
+	}
+	project.Name = name
+	return project
+}
+
+// assertRolledBack checks that a project created and a project updated within
+// a transaction were left as they were before it.
+func assertRolledBack(t *testing.T, repo Repository, created, updated *model.Project) {
+	t.Helper()
+	ctx := context.Background()
+	if exists, err := repo.KeyExists(ctx, created.Key); err != nil || exists {
+		t.Errorf("KeyExists(%s) = %v, %v; want false after rollback", created.Key, exists, err)
+	}
+	if _, err := repo.GetMember(ctx, created.ID, 1); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("GetMember after rollback: got error %v, want ErrNotFound", err)
+	}
+	got := mustGet(t, repo, updated.ID)
+	if got.Name != "Gemini" || got.Version != 1 {
+		t.Errorf("got %q at version %d, want the update rolled back", got.Name, got.Version)
+	}
+}
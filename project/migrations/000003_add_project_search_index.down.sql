@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS project_search_idx;
+ALTER TABLE project DROP COLUMN IF EXISTS search;
\ No newline at end of file
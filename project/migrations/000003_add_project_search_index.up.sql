@@ -0,0 +1,4 @@
+ALTER TABLE project ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', name), 'A') || setweight(to_tsvector('english', description), 'B')
+) STORED;
+CREATE INDEX IF NOT EXISTS project_search_idx ON project USING GIN (search);
\ No newline at end of file
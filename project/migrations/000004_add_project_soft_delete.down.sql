@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS project_deleted_on_idx;
+ALTER TABLE project DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE project DROP COLUMN IF EXISTS deleted_on;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE project ADD COLUMN IF NOT EXISTS deleted_on timestamp(0) with time zone;
+ALTER TABLE project ADD COLUMN IF NOT EXISTS deleted_by bigint;
+CREATE INDEX IF NOT EXISTS project_deleted_on_idx ON project (deleted_on) WHERE deleted_on IS NOT NULL;
\ No newline at end of file
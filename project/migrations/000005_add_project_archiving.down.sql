@@ -0,0 +1,2 @@
+ALTER TABLE project DROP COLUMN IF EXISTS archived_by;
+ALTER TABLE project DROP COLUMN IF EXISTS archived_on;
\ No newline at end of file
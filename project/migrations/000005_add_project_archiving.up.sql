@@ -0,0 +1,2 @@
+ALTER TABLE project ADD COLUMN IF NOT EXISTS archived_on timestamp(0) with time zone;
+ALTER TABLE project ADD COLUMN IF NOT EXISTS archived_by bigint;
\ No newline at end of file
@@ -0,0 +1,5 @@
+UPDATE project SET actual_end_date = GREATEST(created_on, start_date) WHERE actual_end_date IS NULL;
+ALTER TABLE project ALTER COLUMN actual_end_date SET DEFAULT NOW();
+ALTER TABLE project ALTER COLUMN actual_end_date SET NOT NULL;
+ALTER TABLE project DROP CONSTRAINT IF EXISTS project_status_check;
+ALTER TABLE project DROP COLUMN IF EXISTS status;
@@ -0,0 +1,4 @@
+ALTER TABLE project ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'planned';
+ALTER TABLE project ADD CONSTRAINT project_status_check CHECK (status IN ('planned', 'active', 'on_hold', 'completed', 'cancelled'));
+ALTER TABLE project ALTER COLUMN actual_end_date DROP NOT NULL;
+ALTER TABLE project ALTER COLUMN actual_end_date DROP DEFAULT;
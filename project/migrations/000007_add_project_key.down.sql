@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS project_key_idx;
+ALTER TABLE project DROP COLUMN IF EXISTS key;
\ No newline at end of file
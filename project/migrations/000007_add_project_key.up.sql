@@ -0,0 +1,4 @@
+ALTER TABLE project ADD COLUMN IF NOT EXISTS key text;
+UPDATE project SET key = 'P' || id WHERE key IS NULL;
+ALTER TABLE project ALTER COLUMN key SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS project_key_idx ON project (key);
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS project_member(
+    project_id bigint NOT NULL REFERENCES project ON DELETE CASCADE,
+    user_id bigint NOT NULL,
+    role text NOT NULL,
+    added_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    added_by bigint NOT NULL,
+    PRIMARY KEY (project_id, user_id),
+    CONSTRAINT project_member_role_check CHECK (role IN ('owner', 'admin', 'member', 'viewer'))
+);
+CREATE INDEX IF NOT EXISTS project_member_user_id_idx ON project_member (user_id);
+INSERT INTO project_member (project_id, user_id, role, added_by)
+SELECT id, created_by, 'owner', created_by FROM project
+ON CONFLICT DO NOTHING;
\ No newline at end of file
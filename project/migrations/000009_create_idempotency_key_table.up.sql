@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS idempotency_key(
+    user_id bigint NOT NULL,
+    key text NOT NULL,
+    request_hash text NOT NULL,
+    response jsonb NOT NULL,
+    created_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    expires_on timestamp(0) with time zone NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+CREATE INDEX IF NOT EXISTS idempotency_key_expires_on_idx ON idempotency_key (expires_on);
\ No newline at end of file
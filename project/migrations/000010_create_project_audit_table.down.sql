@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS project_audit;
+DROP FUNCTION IF EXISTS project_audit_immutable();
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS project_audit(
+    id bigserial PRIMARY KEY,
+    project_id bigint NOT NULL,
+    version bigint NOT NULL,
+    action text NOT NULL,
+    actor bigint NOT NULL,
+    created_on timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    changes jsonb NOT NULL
+);
+CREATE INDEX IF NOT EXISTS project_audit_project_id_idx ON project_audit (project_id, version);
+CREATE OR REPLACE FUNCTION project_audit_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'project_audit entries cannot be changed';
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER project_audit_immutable BEFORE UPDATE OR DELETE ON project_audit
+FOR EACH ROW EXECUTE FUNCTION project_audit_immutable();
\ No newline at end of file
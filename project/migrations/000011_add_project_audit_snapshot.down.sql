@@ -0,0 +1 @@
+ALTER TABLE project_audit DROP COLUMN IF EXISTS snapshot;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE project_audit ADD COLUMN IF NOT EXISTS snapshot jsonb;
\ No newline at end of file
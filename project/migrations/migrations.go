@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL schema migrations of the project service.
+package migrations
+
+import "embed"
+
+// FS holds the up and down migration files, named <version>_<name>.<up|down>.sql.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// AuditAction defines the kind of change an audit entry records.
+type AuditAction string
+
+// Supported audit actions.
+const (
+	AuditCreate     AuditAction = "create"
+	AuditUpdate     AuditAction = "update"
+	AuditTransition AuditAction = "transition"
+	AuditArchive    AuditAction = "archive"
+	AuditUnarchive  AuditAction = "unarchive"
+	AuditDelete     AuditAction = "delete"
+	AuditRestore    AuditAction = "restore"
+	AuditRevert     AuditAction = "revert"
+)
+
+// AuditEntry records a change to a project: who made it, when, the version of the
+// project it produced and the value of each changed field before and after.
+type AuditEntry struct {
+	ID        int64             `json:"id"`
+	ProjectID int64             `json:"project_id"`
+	Version   int64             `json:"version"`
+	Action    AuditAction       `json:"action"`
+	Actor     int64             `json:"actor"`
+	CreatedOn time.Time         `json:"created_on"`
+	Changes   map[string]Change `json:"changes"`
+	Snapshot  *Project          `json:"-"` // holds the project as the change left it, if recorded.
+}
+
+// Change defines the value of a project field before and after a change, in
+// its JSON form. A nil value means the field was not set.
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// untrackedFields holds the project fields which are not recorded in audit entries
+// because every change sets them, and the entry itself records their value.
+var untrackedFields = []string{"id", "version", "modified_on", "modified_by"}
+
+// DiffProjects returns the fields which differ between two versions of a project,
+// keyed by their JSON names. A nil before compares after with an empty project.
+func DiffProjects(before, after *Project) map[string]Change {
+	if before == nil {
+		before = &Project{}
+	}
+	b, a := projectFields(before), projectFields(after)
+	for _, field := range untrackedFields {
+		delete(b, field)
+		delete(a, field)
+	}
+	changes := map[string]Change{}
+	for field, value := range a {
+		if !reflect.DeepEqual(b[field], value) {
+			changes[field] = Change{Before: b[field], After: value}
+		}
+	}
+	for field, value := range b {
+		if _, ok := a[field]; !ok {
+			changes[field] = Change{Before: value}
+		}
+	}
+	return changes
+}
+
+// projectFields returns the fields of a project in their JSON form, keyed by their JSON
+// names. Times are converted to UTC so that equal instants have equal values.
+func projectFields(project *Project) map[string]interface{} {
+	p := *project
+	for _, t := range []*time.Time{&p.StartDate, &p.TargetEndDate, &p.CreatedOn, &p.ModifiedOn} {
+		*t = t.UTC()
+	}
+	if p.ActualEndDate != nil {
+		actualEndDate := p.ActualEndDate.UTC()
+		p.ActualEndDate = &actualEndDate
+	}
+	if p.DeletedOn != nil {
+		deletedOn := p.DeletedOn.UTC()
+		p.DeletedOn = &deletedOn
+	}
+	if p.ArchivedOn != nil {
+		archivedOn := p.ArchivedOn.UTC()
+		p.ArchivedOn = &archivedOn
+	}
+	js, _ := json.Marshal(p)
+	fields := map[string]interface{}{}
+	json.Unmarshal(js, &fields)
+	return fields
+}
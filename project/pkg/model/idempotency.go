@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/emzola/venato/project/pkg/validator"
+)
+
+// IdempotencyKey records the response to a request made with an idempotency key,
+// so that retries of the request receive the same response.
+type IdempotencyKey struct {
+	UserID      int64
+	Key         string
+	RequestHash string
+	Response    []byte
+	CreatedOn   time.Time
+	ExpiresOn   time.Time
+}
+
+// ValidateIdempotencyKey performs data validation on an idempotency key.
+func ValidateIdempotencyKey(v *validator.Validator, key string) {
+	v.Check(key != "", "idempotency_key", "must be provided")
+	v.Check(len(key) <= 255, "idempotency_key", "must not be more than 255 bytes long")
+}
@@ -1,40 +1,132 @@
 package model
 
 import (
+	"sort"
+
 	"github.com/emzola/venato/gen"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // ProjectToProto converts a Project struct into a generated proto counterpart.
 func ProjectToProto(p *Project) *gen.Project {
-	return &gen.Project{
+	project := &gen.Project{
 		Id:            p.ID,
 		Name:          p.Name,
+		Key:           p.Key,
 		Description:   p.Description,
+		Status:        string(p.Status),
 		StartDate:     timestamppb.New(p.StartDate),
 		TargetEndDate: timestamppb.New(p.TargetEndDate),
-		ActualEndDate: timestamppb.New(p.ActualEndDate),
 		CreatedOn:     timestamppb.New(p.CreatedOn),
 		CreatedBy:     p.CreatedBy,
 		ModifiedOn:    timestamppb.New(p.ModifiedOn),
 		ModifiedBy:    p.ModifiedBy,
 		Version:       p.Version,
+		DeletedBy:     p.DeletedBy,
+		ArchivedBy:    p.ArchivedBy,
+	}
+	if p.ActualEndDate != nil {
+		project.ActualEndDate = timestamppb.New(*p.ActualEndDate)
+	}
+	if p.DeletedOn != nil {
+		project.DeletedOn = timestamppb.New(*p.DeletedOn)
 	}
+	if p.ArchivedOn != nil {
+		project.ArchivedOn = timestamppb.New(*p.ArchivedOn)
+	}
+	return project
 }
 
 // ProjectFromProto converts a generated proto counterpart into a Project struct.
 func ProjectFromProto(p *gen.Project) *Project {
-	return &Project{
+	project := &Project{
 		ID:            p.Id,
 		Name:          p.Name,
+		Key:           p.Key,
 		Description:   p.Description,
+		Status:        ProjectStatus(p.Status),
 		StartDate:     p.StartDate.AsTime(),
 		TargetEndDate: p.TargetEndDate.AsTime(),
-		ActualEndDate: p.ActualEndDate.AsTime(),
 		CreatedOn:     p.CreatedOn.AsTime(),
 		CreatedBy:     p.CreatedBy,
 		ModifiedOn:    p.ModifiedOn.AsTime(),
 		ModifiedBy:    p.ModifiedBy,
 		Version:       p.Version,
+		DeletedBy:     p.DeletedBy,
+		ArchivedBy:    p.ArchivedBy,
+	}
+	if p.ActualEndDate != nil {
+		actualEndDate := p.ActualEndDate.AsTime()
+		project.ActualEndDate = &actualEndDate
+	}
+	if p.DeletedOn != nil {
+		deletedOn := p.DeletedOn.AsTime()
+		project.DeletedOn = &deletedOn
+	}
+	if p.ArchivedOn != nil {
+		archivedOn := p.ArchivedOn.AsTime()
+		project.ArchivedOn = &archivedOn
+	}
+	return project
+}
+
+// MetadataToProto converts a Metadata struct into a generated proto counterpart.
+func MetadataToProto(m Metadata) *gen.Metadata {
+	return &gen.Metadata{
+		CurrentPage:  int32(m.CurrentPage),
+		PageSize:     int32(m.PageSize),
+		FirstPage:    int32(m.FirstPage),
+		LastPage:     int32(m.LastPage),
+		TotalRecords: int32(m.TotalRecords),
+		NextCursor:   m.NextCursor,
+		PrevCursor:   m.PrevCursor,
+	}
+}
+
+// MemberToProto converts a Member struct into a generated proto counterpart.
+func MemberToProto(m *Member) *gen.Member {
+	return &gen.Member{
+		ProjectId: m.ProjectID,
+		UserId:    m.UserID,
+		Role:      string(m.Role),
+		AddedOn:   timestamppb.New(m.AddedOn),
+		AddedBy:   m.AddedBy,
+	}
+}
+
+// AuditEntryToProto converts an AuditEntry struct into a generated proto counterpart.
+// Its changes are ordered by field name.
+func AuditEntryToProto(e *AuditEntry) *gen.AuditEntry {
+	entry := &gen.AuditEntry{
+		Id:        e.ID,
+		ProjectId: e.ProjectID,
+		Version:   e.Version,
+		Action:    string(e.Action),
+		Actor:     e.Actor,
+		CreatedOn: timestamppb.New(e.CreatedOn),
+	}
+	fields := make([]string, 0, len(e.Changes))
+	for field := range e.Changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		change := e.Changes[field]
+		entry.Changes = append(entry.Changes, &gen.FieldChange{
+			Field:  field,
+			Before: valueToProto(change.Before),
+			After:  valueToProto(change.After),
+		})
+	}
+	return entry
+}
+
+// valueToProto converts a decoded JSON value into a generated proto counterpart.
+func valueToProto(v interface{}) *structpb.Value {
+	value, err := structpb.NewValue(v)
+	if err != nil {
+		return structpb.NewNullValue()
 	}
+	return value
 }
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/emzola/venato/project/pkg/validator"
+)
+
+// Role defines the role of a member within a project.
+type Role string
+
+// Supported project member roles.
+const (
+	RoleOwner  Role = "owner"
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+	RoleViewer Role = "viewer"
+)
+
+// Roles holds every supported project member role.
+var Roles = []string{
+	string(RoleOwner),
+	string(RoleAdmin),
+	string(RoleMember),
+	string(RoleViewer),
+}
+
+// Member defines the project membership data.
+type Member struct {
+	ProjectID int64     `json:"project_id"`
+	UserID    int64     `json:"user_id"`
+	Role      Role      `json:"role"`
+	AddedOn   time.Time `json:"added_on"`
+	AddedBy   int64     `json:"added_by"`
+}
+
+// ValidateMember performs data validation on project member data.
+func ValidateMember(v *validator.Validator, member *Member) {
+	v.Check(member.UserID > 0, "user_id", "must be greater than zero")
+	v.Check(validator.In(string(member.Role), Roles...), "role", "must be one of "+strings.Join(Roles, ", "))
+}
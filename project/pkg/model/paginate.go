@@ -1,18 +1,55 @@
 package model
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"math"
 	"strings"
 
 	"github.com/emzola/venato/project/pkg/validator"
 )
 
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
 // Filters defines data used for pagination and sorting.
 type Filters struct {
 	Page         int
 	PageSize     int
 	Sort         string
 	SortSafelist []string // holds supported sort values.
+	CursorMode   bool     // selects keyset pagination instead of page offsets.
+	Cursor       string   // holds the opaque keyset position, empty for the first page.
+}
+
+// Cursor defines a position within a keyset-paginated list. It records the
+// sort it was issued for together with the sort column value and id of the
+// record it points at.
+type Cursor struct {
+	Sort     string `json:"s"`
+	Value    string `json:"v"`
+	ID       int64  `json:"i"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// EncodeCursor serializes a Cursor into an opaque URL-safe string.
+func EncodeCursor(c Cursor) string {
+	js, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(js)
+}
+
+// DecodeCursor de-serializes an opaque string produced by EncodeCursor.
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+	js, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(js, &c); err != nil || c.ID < 1 {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
 }
 
 // ValidateFilters performs data validation on Filters.
@@ -22,6 +59,11 @@ func ValidateFilters(v *validator.Validator, f Filters) {
 	v.Check(f.PageSize > 0, "page_size", "must be greater than zero")
 	v.Check(f.PageSize <= 100, "page_size", "must be a maximum of 100")
 	v.Check(validator.In(f.Sort, f.SortSafelist...), "sort", "invalid sort value")
+	if f.CursorMode && f.Cursor != "" {
+		c, err := DecodeCursor(f.Cursor)
+		v.Check(err == nil, "cursor", "must be a cursor returned by a previous request")
+		v.Check(err != nil || c.Sort == f.Sort, "cursor", "was issued for a different sort value")
+	}
 }
 
 func (f Filters) SortColumn() string {
@@ -50,11 +92,13 @@ func (f Filters) Offset() int {
 }
 
 type Metadata struct {
-	CurrentPage  int `json:"current_page,omitempty"`
-	PageSize     int `json:"page_size,omitempty"`
-	FirstPage    int `json:"first_page,omitempty"`
-	LastPage     int `json:"last_page,omitempty"`
-	TotalRecords int `json:"total_records,omitempty"`
+	CurrentPage  int    `json:"current_page,omitempty"`
+	PageSize     int    `json:"page_size,omitempty"`
+	FirstPage    int    `json:"first_page,omitempty"`
+	LastPage     int    `json:"last_page,omitempty"`
+	TotalRecords int    `json:"total_records,omitempty"`
+	NextCursor   string `json:"next_cursor,omitempty"`
+	PrevCursor   string `json:"prev_cursor,omitempty"`
 }
 
 func CalculateMetadata(totalRecords, page, pageSize int) Metadata {
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/emzola/venato/project/pkg/validator"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []Cursor{
+		{Sort: "id", Value: "1", ID: 1},
+		{Sort: "-name", Value: "Apollo 11: \"Moon\" landing/ü", ID: 42, Backward: true},
+		{Sort: "created_on", Value: "2023-10-01T12:00:00Z", ID: 9_007_199_254_740_993},
+	}
+	for _, want := range tests {
+		s := EncodeCursor(want)
+		got, err := DecodeCursor(s)
+		if err != nil {
+			t.Fatalf("DecodeCursor(EncodeCursor(%+v)): %v", want, err)
+		}
+		if got != want {
+			t.Errorf("got cursor %+v, want %+v", got, want)
+		}
+		if _, err := base64.RawURLEncoding.DecodeString(s); err != nil {
+			t.Errorf("cursor %q is not URL-safe base64: %v", s, err)
+		}
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	valid := EncodeCursor(Cursor{Sort: "id", Value: "7", ID: 7})
+	encode := func(js string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(js))
+	}
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"empty", ""},
+		{"not base64", "not a cursor!"},
+		{"truncated", valid[:len(valid)-3]},
+		{"extra characters", valid + "*"},
+		{"not JSON", encode("id:7")},
+		{"JSON array", encode(`["id","7",7]`)},
+		{"id of the wrong type", encode(`{"s":"id","v":"7","i":"7"}`)},
+		{"missing id", encode(`{"s":"id","v":"7"}`)},
+		{"zero id", encode(`{"s":"id","v":"0","i":0}`)},
+		{"negative id", encode(`{"s":"id","v":"-1","i":-1}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, err := DecodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor(%q) = %+v, %v; want ErrInvalidCursor", tt.cursor, c, err)
+			}
+		})
+	}
+}
+
+func TestValidateFilters(t *testing.T) {
+	safelist := []string{"id", "name", "-id", "-name"}
+	byName := EncodeCursor(Cursor{Sort: "name", Value: "Apollo", ID: 3})
+	tests := []struct {
+		name       string
+		filters    Filters
+		wantFields []string
+	}{
+		{"valid", Filters{Page: 1, PageSize: 20, Sort: "id"}, nil},
+		{"largest page and page size", Filters{Page: 10_000_000, PageSize: 100, Sort: "-name"}, nil},
+		{"zero page", Filters{Page: 0, PageSize: 20, Sort: "id"}, []string{"page"}},
+		{"page too large", Filters{Page: 10_000_001, PageSize: 20, Sort: "id"}, []string{"page"}},
+		{"zero page size", Filters{Page: 1, PageSize: 0, Sort: "id"}, []string{"page_size"}},
+		{"page size too large", Filters{Page: 1, PageSize: 101, Sort: "id"}, []string{"page_size"}},
+		{"unsupported sort", Filters{Page: 1, PageSize: 20, Sort: "name; DROP TABLE project"}, []string{"sort"}},
+		{"empty sort", Filters{Page: 1, PageSize: 20}, []string{"sort"}},
+		{"every field invalid", Filters{Page: -1, PageSize: -1, Sort: "x"}, []string{"page", "page_size", "sort"}},
+		{"first cursor page", Filters{Page: 1, PageSize: 20, Sort: "name", CursorMode: true}, nil},
+		{"cursor", Filters{Page: 1, PageSize: 20, Sort: "name", CursorMode: true, Cursor: byName}, nil},
+		{"cursor for another sort", Filters{Page: 1, PageSize: 20, Sort: "-name", CursorMode: true, Cursor: byName}, []string{"cursor"}},
+		{"tampered cursor", Filters{Page: 1, PageSize: 20, Sort: "name", CursorMode: true, Cursor: "x" + byName}, []string{"cursor"}},
+		{"cursor outside cursor mode", Filters{Page: 1, PageSize: 20, Sort: "name", Cursor: "garbage"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filters.SortSafelist = safelist
+			v := validator.New()
+			ValidateFilters(v, tt.filters)
+			if len(v.Errors) != len(tt.wantFields) {
+				t.Fatalf("got errors %v, want errors for %v", v.Errors, tt.wantFields)
+			}
+			for _, field := range tt.wantFields {
+				if _, ok := v.Errors[field]; !ok {
+					t.Errorf("got errors %v, want an error for %s", v.Errors, field)
+				}
+			}
+		})
+	}
+}
+
+func TestFiltersSort(t *testing.T) {
+	tests := []struct {
+		sort          string
+		wantColumn    string
+		wantDirection string
+	}{
+		{"id", "id", "ASC"},
+		{"-id", "id", "DESC"},
+		{"name", "name", "ASC"},
+		{"-name", "name", "DESC"},
+	}
+	for _, tt := range tests {
+		f := Filters{Sort: tt.sort, SortSafelist: []string{"id", "name", "-id", "-name"}}
+		if got := f.SortColumn(); got != tt.wantColumn {
+			t.Errorf("SortColumn() for %q = %q, want %q", tt.sort, got, tt.wantColumn)
+		}
+		if got := f.SortDirection(); got != tt.wantDirection {
+			t.Errorf("SortDirection() for %q = %q, want %q", tt.sort, got, tt.wantDirection)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("SortColumn() did not panic for a sort value outside the safelist")
+		}
+	}()
+	Filters{Sort: "password", SortSafelist: []string{"id"}}.SortColumn()
+}
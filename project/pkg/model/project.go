@@ -1,30 +1,111 @@
 package model
 
 import (
+	"regexp"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/emzola/venato/project/pkg/validator"
 )
 
+// KeyRX matches a project key: an uppercase letter followed by uppercase letters or digits.
+var KeyRX = regexp.MustCompile("^[A-Z][A-Z0-9]+$")
+
+// ProjectStatus defines the lifecycle status of a project.
+type ProjectStatus string
+
+// Supported project statuses.
+const (
+	StatusPlanned   ProjectStatus = "planned"
+	StatusActive    ProjectStatus = "active"
+	StatusOnHold    ProjectStatus = "on_hold"
+	StatusCompleted ProjectStatus = "completed"
+	StatusCancelled ProjectStatus = "cancelled"
+)
+
+// ProjectStatuses holds every supported project status.
+var ProjectStatuses = []string{
+	string(StatusPlanned),
+	string(StatusActive),
+	string(StatusOnHold),
+	string(StatusCompleted),
+	string(StatusCancelled),
+}
+
 // Project defines the project data.
 type Project struct {
-	ID            int64     `json:"id"`
-	Name          string    `json:"name"`
-	Description   string    `json:"description"`
-	StartDate     time.Time `json:"start_date"`
-	TargetEndDate time.Time `json:"target_end_date"`
-	ActualEndDate time.Time `json:"actual_end_date,omitempty"`
-	CreatedOn     time.Time `json:"created_on"`
-	CreatedBy     int64     `json:"created_by"`
-	ModifiedOn    time.Time `json:"modified_on,omitempty"`
-	ModifiedBy    int64     `json:"modified_by,omitempty"`
-	Version       int64     `json:"version"`
+	ID            int64         `json:"id"`
+	Name          string        `json:"name"`
+	Key           string        `json:"key"`
+	Description   string        `json:"description"`
+	Status        ProjectStatus `json:"status"`
+	StartDate     time.Time     `json:"start_date"`
+	TargetEndDate time.Time     `json:"target_end_date"`
+	ActualEndDate *time.Time    `json:"actual_end_date,omitempty"`
+	CreatedOn     time.Time     `json:"created_on"`
+	CreatedBy     int64         `json:"created_by"`
+	ModifiedOn    time.Time     `json:"modified_on,omitempty"`
+	ModifiedBy    int64         `json:"modified_by,omitempty"`
+	Version       int64         `json:"version"`
+	DeletedOn     *time.Time    `json:"deleted_on,omitempty"`
+	DeletedBy     int64         `json:"deleted_by,omitempty"`
+	ArchivedOn    *time.Time    `json:"archived_on,omitempty"`
+	ArchivedBy    int64         `json:"archived_by,omitempty"`
+}
+
+// ProjectQuery defines the criteria used to narrow down a list of projects.
+type ProjectQuery struct {
+	Name     string // matches project names exactly, ignoring case.
+	Search   string // matches words in the project name or description.
+	Trashed  bool   // lists projects in the trash instead of active ones.
+	Archived bool   // lists archived projects instead of active ones.
+	MemberID int64  // lists only projects the user is a member of, when set.
 }
 
 // ValidateProject performs data validation on project data.
 func ValidateProject(v *validator.Validator, project *Project) {
 	v.Check(project.Name != "", "name", "must be provided")
 	v.Check(len(project.Name) <= 500, "name", "must not be more than 500 bytes long")
+	v.Check(project.Key != "", "key", "must be provided")
+	v.Check(len(project.Key) >= 2, "key", "must be at least 2 characters long")
+	v.Check(len(project.Key) <= 10, "key", "must not be more than 10 characters long")
+	v.Check(validator.Matches(project.Key, KeyRX), "key", "must start with a letter and contain only uppercase letters and digits")
 	v.Check(len(project.Description) <= 1000, "description", "must not be more than 1000 bytes long")
-	v.Check(project.TargetEndDate.After(project.StartDate), "target end date", "must not be before start date")
+	v.Check(project.TargetEndDate.After(project.StartDate), "target_end_date", "must not be before start date")
+	ValidateStatus(v, project.Status)
+}
+
+// ValidateStatus performs data validation on a project status.
+func ValidateStatus(v *validator.Validator, status ProjectStatus) {
+	v.Check(validator.In(string(status), ProjectStatuses...), "status", "must be one of "+strings.Join(ProjectStatuses, ", "))
+}
+
+// SuggestKey derives a project key from a project name. Multi-word names
+// use the initials of each word, e.g. "Venato Issue Tracker" becomes "VIT",
+// while single-word names use their first three characters, e.g. "Venato"
+// becomes "VEN". It returns "PRJ" if the name contains no usable characters.
+func SuggestKey(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+	var key string
+	if len(words) == 1 {
+		key = words[0]
+		if len(key) > 3 {
+			key = key[:3]
+		}
+	} else {
+		for _, word := range words {
+			key += word[:1]
+		}
+	}
+	key = strings.TrimLeftFunc(strings.ToUpper(key), unicode.IsDigit)
+	if len(key) > 10 {
+		key = key[:10]
+	}
+	if len(key) < 2 {
+		return "PRJ"
+	}
+	return key
 }